@@ -2,10 +2,26 @@ package gomk
 
 import (
 	"context"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"git.fractalqb.de/fractalqb/gomk/gomkore"
 )
 
+// SignalContext returns a context that is cancelled on SIGINT or SIGTERM. Use
+// it with [gomkore.NewTrace] to interrupt builds gracefully. After the first
+// signal, the default signal handling is restored, i.e. a second signal
+// terminates the program immediately.
+func SignalContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
 func NewBuilder(tr *gomkore.Trace, env *gomkore.Env) *gomkore.Builder {
 	if tr == nil {
 		tr = gomkore.NewTrace(context.Background(), NewDefaultTracer())
@@ -28,3 +44,16 @@ func Clean(prj *gomkore.Project, dryrun bool, tr *gomkore.Trace) error {
 	}
 	return gomkore.Clean(prj, dryrun, tr)
 }
+
+// CleanLabeled cleans the goals of prj that match the label expression expr,
+// see [gomkore.ParseLabelExpr].
+func CleanLabeled(prj *gomkore.Project, expr string, dryrun bool, tr *gomkore.Trace) error {
+	lx, err := gomkore.ParseLabelExpr(expr)
+	if err != nil {
+		return err
+	}
+	if tr == nil {
+		tr = gomkore.NewTrace(context.Background(), NewDefaultTracer())
+	}
+	return gomkore.CleanGoals(prj, prj.SelectGoals(lx), dryrun, tr)
+}
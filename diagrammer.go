@@ -11,162 +11,448 @@ import (
 	"git.fractalqb.de/fractalqb/gomk/gomkore"
 )
 
+// Diagrammer draws the graph of goals and actions of a project.
 type Diagrammer struct {
+	// RankDir is the direction of the diagram, one of TB, LR, BT or RL.
 	RankDir string
+
+	// Select restricts the diagram to the goals that match the label
+	// expression, the actions that result in them and their premises. If nil,
+	// the complete project is drawn.
+	Select gomkore.LabelExpr
+
+	// Plan makes the diagram show the state of goals if it is set. Goals are
+	// coloured as up-to-date, outdated if the plan would update them, missing
+	// if their artefact does not exist or failed if one of their actions
+	// failed in the last build, see [gomkore.Action.LastError]. Actions the
+	// plan would run are coloured as outdated. Edges from the premises that
+	// trigger an action and from planned actions to their results are
+	// highlighted. Use [Builder.Plan] to compute the plan of a dry run. Set the
+	// project's State to know failures of builds in other processes.
+	Plan *gomkore.Plan
 }
 
+// WriteDot writes the diagram of prj as Graphviz dot file to w.
 func (dia *Diagrammer) WriteDot(w io.Writer, prj *gomkore.Project) (err error) {
-	defer func() {
-		if p := recover(); p != nil {
-			switch p := p.(type) {
-			case error:
-				err = p
-			case string:
-				err = errors.New(p)
-			default:
-				err = fmt.Errorf("panic: %+v", p)
-			}
+	defer recoverDiagram(&err)
+	g, err := dia.graph(prj)
+	if err != nil {
+		return err
+	}
+	writeDot(w, g, dia.RankDir)
+	return nil
+}
+
+func recoverDiagram(err *error) {
+	if p := recover(); p != nil {
+		switch p := p.(type) {
+		case error:
+			*err = p
+		case string:
+			*err = errors.New(p)
+		default:
+			*err = fmt.Errorf("panic: %+v", p)
 		}
-	}()
+	}
+}
 
-	dia.startDot(w, prj)
-	for _, g := range prj.Goals(nil) {
-		dia.goal(w, g)
+func (dia *Diagrammer) selection(prj *gomkore.Project) (gs []*gomkore.Goal, as []*gomkore.Action) {
+	if dia.Select == nil {
+		return prj.Goals(nil), prj.Actions()
+	}
+	sel := make(map[*gomkore.Goal]bool)
+	for _, g := range prj.SelectGoals(dia.Select) {
+		sel[g] = true
 	}
 	for _, a := range prj.Actions() {
-		dia.action(w, a)
+		if slices.IndexFunc(a.Results(), func(g *gomkore.Goal) bool { return sel[g] }) >= 0 {
+			as = append(as, a)
+		}
 	}
-	dia.endDot(w)
-	return nil
+	for _, a := range as {
+		for _, g := range a.Premises() {
+			sel[g] = true
+		}
+		for _, g := range a.Results() {
+			sel[g] = true
+		}
+	}
+	for _, g := range prj.Goals(nil) {
+		if sel[g] {
+			gs = append(gs, g)
+		}
+	}
+	return gs, as
 }
 
-func (dia *Diagrammer) startDot(w io.Writer, prj *gomkore.Project) {
-	fmt.Fprintf(w, "digraph \"%s\" {\n", escDotID(prj.Name(nil)))
-	if dia.RankDir != "" {
-		fmt.Fprintf(w, "\trankdir=\"%s\"\n", escDotID(dia.RankDir))
+// diaGraph is the format independent model of a diagram. Each output format
+// renders a diaGraph.
+type diaGraph struct {
+	name  string
+	plan  *gomkore.Plan
+	nodes []*diaNode
+	edges []diaEdge
+}
+
+type diaNodeKind int
+
+const (
+	diaAbstract diaNodeKind = iota // abstract goal
+	diaTangible                    // tangible goal
+	diaAction                      // action with an operation
+	diaJoint                       // implicit action that joins goals
+)
+
+func (k diaNodeKind) String() string {
+	switch k {
+	case diaAbstract:
+		return "abstract"
+	case diaTangible:
+		return "tangible"
+	case diaAction:
+		return "action"
+	case diaJoint:
+		return "joint"
 	}
+	return fmt.Sprintf("diaNodeKind(%d)", int(k))
 }
 
-func (dia *Diagrammer) endDot(w io.Writer) {
-	fmt.Fprintln(w, "}")
+type diaState int
+
+const (
+	diaNoState diaState = iota
+	diaUpToDate
+	diaOutdated
+	diaMissing
+	diaFailed
+)
+
+func (s diaState) String() string {
+	switch s {
+	case diaNoState:
+		return ""
+	case diaUpToDate:
+		return "up-to-date"
+	case diaOutdated:
+		return "outdated"
+	case diaMissing:
+		return "missing"
+	case diaFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("diaState(%d)", int(s))
 }
 
-func (dia *Diagrammer) goal(w io.Writer, g *gomkore.Goal) {
-	var style string
-	if g.IsAbstract() {
-		if len(g.ResultOf()) == 0 || len(g.PremiseOf()) == 0 {
-			style = "dashed,bold"
-		} else {
-			style = "dashed"
-		}
-		fmt.Fprintf(w, "\t\"%p\" [shape=box,style=\"%s\",label=\"%s\"];\n",
-			g,
-			style,
-			g.Name(),
-		)
-		return
-	} else if len(g.ResultOf()) == 0 || len(g.PremiseOf()) == 0 {
-		style = ",style=bold"
+// color returns the colour name of s that is understood by all output
+// formats.
+func (s diaState) color() string {
+	switch s {
+	case diaUpToDate:
+		return "palegreen"
+	case diaOutdated:
+		return "gold"
+	case diaMissing:
+		return "lightgray"
+	case diaFailed:
+		return "lightcoral"
 	}
+	return ""
+}
 
-	atfType := reflect.Indirect(reflect.ValueOf(g.Artefact)).Type().Name()
+// diaTriggerColor is the colour of edges that trigger a rebuild.
+const diaTriggerColor = "red"
 
-	var updMode string
-	if len(g.ResultOf()) > 1 {
-		switch g.UpdateMode.Actions() {
-		case UpdOneAction:
-			updMode = " 1"
-		case UpdAnyAction:
-			updMode = " ?"
-		case UpdSomeActions:
-			updMode = " *"
-		case UpdAllActions:
-			updMode = " !"
+type diaNode struct {
+	id    string
+	kind  diaNodeKind
+	label string
+	state diaState
+	// atfType is the artefact type of a tangible goal
+	atfType string
+	// updMode is the update mode marker of a tangible goal, see updModeMark
+	updMode string
+	// bold marks goals without premises or results and actions without
+	// premises
+	bold bool
+}
+
+type diaEdge struct {
+	from, to string
+	// order is the 1-based index of the action in the result's actions if
+	// the result has an ordered update mode, otherwise 0.
+	order int
+	// implicit edges belong to actions without operation
+	implicit bool
+	// noHead is set for edges from premises to joints
+	noHead bool
+	// trigger marks edges that trigger a rebuild according to the plan
+	trigger bool
+	// after is the number of nodes added to the graph before the edge. Dot
+	// output writes each action's node followed by its edges.
+	after int
+}
+
+func (g *diaGraph) edge(e diaEdge) {
+	e.after = len(g.nodes)
+	g.edges = append(g.edges, e)
+}
+
+func (dia *Diagrammer) graph(prj *gomkore.Project) (*diaGraph, error) {
+	gs, as := dia.selection(prj)
+	g := &diaGraph{name: prj.Name(nil), plan: dia.Plan}
+	for _, goal := range gs {
+		n := goalNode(goal)
+		if dia.Plan != nil {
+			var err error
+			if n.state, err = goalState(goal, dia.Plan); err != nil {
+				return nil, err
+			}
+		}
+		g.nodes = append(g.nodes, n)
+	}
+	for _, a := range as {
+		g.action(a)
+	}
+	return g, nil
+}
+
+func goalState(g *gomkore.Goal, pl *gomkore.Plan) (diaState, error) {
+	for _, a := range g.ResultOf() {
+		if a.LastError() != nil {
+			return diaFailed, nil
 		}
 	}
+	if !g.IsAbstract() {
+		t, err := g.Artefact.StateAt(g.Project())
+		if err != nil {
+			return diaNoState, err
+		}
+		if t.IsZero() {
+			return diaMissing, nil
+		}
+	}
+	if pl.Planned(g) {
+		return diaOutdated, nil
+	}
+	return diaUpToDate, nil
+}
 
-	fmt.Fprintf(w, "\t\"%p\" [shape=record%s,label=\"{%s%s|%s}\"];\n",
-		g,
-		style,
-		atfType,
-		updMode,
-		g.Name(),
-	)
+func actionState(a *gomkore.Action, pl *gomkore.Plan) diaState {
+	switch _, planned := pl.Schedule(a); {
+	case pl == nil:
+		return diaNoState
+	case a.LastError() != nil:
+		return diaFailed
+	case planned:
+		return diaOutdated
+	}
+	return diaUpToDate
 }
 
-func (dia *Diagrammer) action(w io.Writer, a *gomkore.Action) {
-	toRes := func(res *gomkore.Goal, implicit bool) {
+func goalID(g *gomkore.Goal) string     { return fmt.Sprintf("g%p", g) }
+func actionID(a *gomkore.Action) string { return fmt.Sprintf("a%p", a) }
+
+func goalNode(g *gomkore.Goal) *diaNode {
+	n := &diaNode{
+		id:    goalID(g),
+		label: g.Name(),
+		bold:  len(g.ResultOf()) == 0 || len(g.PremiseOf()) == 0,
+	}
+	if g.IsAbstract() {
+		n.kind = diaAbstract
+	} else {
+		n.kind = diaTangible
+		n.atfType = artefactType(g)
+		n.updMode = updModeMark(g)
+	}
+	return n
+}
+
+func (g *diaGraph) action(a *gomkore.Action) {
+	aID := actionID(a)
+	sched, planned := g.plan.Schedule(a)
+	// triggers reports whether the edge from pre triggers a
+	triggers := func(pre *gomkore.Goal) bool {
+		return planned && sched.Premise == pre
+	}
+	toRes := func(from string, res *gomkore.Goal, implicit, trigger bool) {
+		e := diaEdge{from: from, to: goalID(res), implicit: implicit, trigger: trigger}
 		if res.UpdateMode.Ordered() {
-			i := slices.Index(res.ResultOf(), a)
-			if implicit {
-				fmt.Fprintf(w, "\t\"%p\" -> \"%p\" [style=dashed,label=\"%d\"];\n",
-					a,
-					res,
-					i+1,
-				)
-			} else {
-				fmt.Fprintf(w, "\t\"%p\" -> \"%p\" [label=\"%d\"];\n",
-					a,
-					res,
-					i+1,
-				)
-			}
-		} else if implicit {
-			fmt.Fprintf(w, "\t\"%p\" -> \"%p\" [style=dashed];\n", a, res)
-		} else {
-			fmt.Fprintf(w, "\t\"%p\" -> \"%p\";\n", a, res)
+			e.order = slices.Index(res.ResultOf(), a) + 1
 		}
+		g.edge(e)
+	}
+	fromAction := func(res *gomkore.Goal, implicit bool) {
+		toRes(aID, res, implicit, planned && g.plan.Planned(res))
 	}
 
 	if a.Op == nil {
 		if len(a.Results()) > 1 || len(a.Premises()) > 1 {
-			fmt.Fprintf(w, "\t\"%p\" [shape=point];\n", a)
+			g.nodes = append(g.nodes, &diaNode{id: aID, kind: diaJoint})
 			for _, pre := range a.Premises() {
-				fmt.Fprintf(w, "\t\"%p\" -> \"%p\" [style=dashed, arrowhead=none];\n", pre, a)
+				g.edge(diaEdge{
+					from:     goalID(pre),
+					to:       aID,
+					implicit: true,
+					noHead:   true,
+					trigger:  triggers(pre),
+				})
 			}
 			for _, res := range a.Results() {
-				toRes(res, true)
+				fromAction(res, true)
 			}
 		} else if len(a.Premises()) == 0 {
-			fmt.Fprintf(w, "\t\"%p\" [shape=point];\n", a)
-			toRes(a.Result(0), true)
-		} else if res := a.Result(0); res.UpdateMode.Ordered() {
-			i := slices.Index(res.ResultOf(), a)
-			fmt.Fprintf(w, "\t\"%p\" -> \"%p\" [style=dashed,label=\"%d\"];\n",
-				a.Premise(0),
-				res,
-				i+1,
-			)
+			g.nodes = append(g.nodes, &diaNode{id: aID, kind: diaJoint})
+			fromAction(a.Result(0), true)
 		} else {
-			fmt.Fprintf(w, "\t\"%p\" -> \"%p\" [style=dashed];\n",
-				a.Premise(0),
-				res,
-			)
+			pre := a.Premise(0)
+			toRes(goalID(pre), a.Result(0), true, triggers(pre))
 		}
 		return
 	}
 
-	if len(a.Premises()) == 0 {
-		fmt.Fprintf(w,
-			"\t\"%p\" [shape=box,style=\"rounded,bold\",label=\"%s\"];\n",
-			a,
-			escDotID(a.String()),
+	g.nodes = append(g.nodes, &diaNode{
+		id:    aID,
+		kind:  diaAction,
+		label: a.String(),
+		state: actionState(a, g.plan),
+		bold:  len(a.Premises()) == 0,
+	})
+	for _, pre := range a.Premises() {
+		g.edge(diaEdge{from: goalID(pre), to: aID, trigger: triggers(pre)})
+	}
+	for _, res := range a.Results() {
+		fromAction(res, false)
+	}
+}
+
+func artefactType(g *gomkore.Goal) string {
+	return reflect.Indirect(reflect.ValueOf(g.Artefact)).Type().Name()
+}
+
+// updModeMark returns the marker for the update mode of goals that are the
+// result of more than one action.
+func updModeMark(g *gomkore.Goal) string {
+	if len(g.ResultOf()) > 1 {
+		switch g.UpdateMode.Actions() {
+		case UpdOneAction:
+			return " 1"
+		case UpdAnyAction:
+			return " ?"
+		case UpdSomeActions:
+			return " *"
+		case UpdAllActions:
+			return " !"
+		}
+	}
+	return ""
+}
+
+func writeDot(w io.Writer, g *diaGraph, rankDir string) {
+	fmt.Fprintf(w, "digraph \"%s\" {\n", escDotID(g.name))
+	if rankDir != "" {
+		fmt.Fprintf(w, "\trankdir=\"%s\"\n", escDotID(rankDir))
+	}
+	edges := g.edges
+	for i, n := range g.nodes {
+		for len(edges) > 0 && edges[0].after <= i {
+			writeDotEdge(w, edges[0])
+			edges = edges[1:]
+		}
+		writeDotNode(w, n)
+	}
+	for _, e := range edges {
+		writeDotEdge(w, e)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// dotID strips the kind prefix from the node IDs of the diagram model. Dot
+// identifies nodes by the quoted address of the goal or action.
+func dotID(id string) string { return id[1:] }
+
+func writeDotNode(w io.Writer, n *diaNode) {
+	var style []string
+	switch n.kind {
+	case diaAbstract:
+		style = append(style, "dashed")
+	case diaAction:
+		style = append(style, "rounded")
+	}
+	if n.bold {
+		style = append(style, "bold")
+	}
+	var fill string
+	if c := n.state.color(); c != "" {
+		style = append(style, "filled")
+		fill = fmt.Sprintf(",fillcolor=\"%s\"", c)
+	}
+	var attrs string
+	if len(style) > 0 {
+		attrs = fmt.Sprintf(",style=\"%s\"%s", strings.Join(style, ","), fill)
+	}
+	switch n.kind {
+	case diaAbstract:
+		fmt.Fprintf(w, "\t\"%s\" [shape=box%s,label=\"%s\"];\n",
+			dotID(n.id),
+			attrs,
+			escDotID(n.label),
 		)
-	} else {
-		fmt.Fprintf(w,
-			"\t\"%p\" [shape=box,style=\"rounded\",label=\"%s\"];\n",
-			a,
-			escDotID(a.String()),
+	case diaTangible:
+		fmt.Fprintf(w, "\t\"%s\" [shape=record%s,label=\"{%s%s|%s}\"];\n",
+			dotID(n.id),
+			attrs,
+			n.atfType,
+			n.updMode,
+			escDotRecord(n.label),
+		)
+	case diaAction:
+		fmt.Fprintf(w, "\t\"%s\" [shape=box%s,label=\"%s\"];\n",
+			dotID(n.id),
+			attrs,
+			escDotID(n.label),
 		)
+	case diaJoint:
+		fmt.Fprintf(w, "\t\"%s\" [shape=point];\n", dotID(n.id))
 	}
-	for _, pre := range a.Premises() {
-		fmt.Fprintf(w, "\t\"%p\" -> \"%p\";\n", pre, a)
+}
+
+func writeDotEdge(w io.Writer, e diaEdge) {
+	var attrs []string
+	if e.implicit {
+		attrs = append(attrs, "style=dashed")
 	}
-	for _, res := range a.Results() {
-		toRes(res, false)
+	if e.noHead {
+		attrs = append(attrs, "arrowhead=none")
+	}
+	if e.order > 0 {
+		attrs = append(attrs, fmt.Sprintf("label=\"%d\"", e.order))
+	}
+	if e.trigger {
+		attrs = append(attrs, fmt.Sprintf("color=\"%s\",penwidth=2", diaTriggerColor))
+	}
+	if len(attrs) == 0 {
+		fmt.Fprintf(w, "\t\"%s\" -> \"%s\";\n", dotID(e.from), dotID(e.to))
+	} else {
+		fmt.Fprintf(w, "\t\"%s\" -> \"%s\" [%s];\n",
+			dotID(e.from),
+			dotID(e.to),
+			strings.Join(attrs, ","),
+		)
 	}
 }
 
 func escDotID(id string) string {
 	return strings.ReplaceAll(id, "\"", "\\\"")
 }
+
+var dotRecordEsc = strings.NewReplacer(
+	"\"", "\\\"",
+	"{", "\\{",
+	"}", "\\}",
+	"|", "\\|",
+	"<", "\\<",
+	">", "\\>",
+)
+
+func escDotRecord(s string) string { return dotRecordEsc.Replace(s) }
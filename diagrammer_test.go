@@ -0,0 +1,284 @@
+package gomk
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/gomk/gomktest"
+	"git.fractalqb.de/fractalqb/gomk/mkfs"
+	"git.fractalqb.de/fractalqb/testerr"
+)
+
+type diagramTest struct {
+	prj           *gomkore.Project
+	src, obj, all *gomkore.Goal
+	cc            *gomkore.Action
+}
+
+func newDiagramTest(t *testing.T) (dt diagramTest) {
+	dt.prj = gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(dt.prj, func(prj ProjectEd) {
+		s := prj.Goal(mkfs.File("a.c"))
+		o, a := prj.Goal(mkfs.File(`"a".o`)).By(OpFunc("cc", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			return nil
+		}), s)
+		dt.all = prj.AbstractGoal("all").ImpliedBy(o).Goal()
+		dt.src, dt.obj, dt.cc = s.Goal(), o.Goal(), a.Action()
+	})).BeNil(t)
+	return dt
+}
+
+func expectLines(t *testing.T, out string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(out, line) {
+			t.Errorf("missing line %q in:\n%s", line, out)
+		}
+	}
+}
+
+func TestDiagrammer_WriteDot(t *testing.T) {
+	dt := newDiagramTest(t)
+	var buf bytes.Buffer
+	dia := Diagrammer{RankDir: "LR"}
+	testerr.Shall(dia.WriteDot(&buf, dt.prj)).BeNil(t)
+	out := buf.String()
+	if !strings.HasPrefix(out, "digraph \"TestDiagrammer_WriteDot\" {\n\trankdir=\"LR\"\n") {
+		t.Errorf("missing digraph header:\n%s", out)
+	}
+	expectLines(t, out,
+		fmt.Sprintf("\t\"%p\" [shape=record,style=\"bold\",label=\"{File|a.c}\"];\n", dt.src),
+		fmt.Sprintf("\t\"%p\" [shape=record,label=\"{File|\\\"a\\\".o}\"];\n", dt.obj),
+		fmt.Sprintf("\t\"%p\" [shape=box,style=\"dashed,bold\",label=\"all\"];\n", dt.all),
+	)
+	// Goals come first, then each action followed by its edges
+	actions := fmt.Sprintf("\t\"%p\" [shape=box,style=\"rounded\",label=\"cc\"];\n", dt.cc) +
+		fmt.Sprintf("\t\"%p\" -> \"%p\";\n", dt.src, dt.cc) +
+		fmt.Sprintf("\t\"%p\" -> \"%p\" [label=\"1\"];\n", dt.cc, dt.obj) +
+		fmt.Sprintf("\t\"%p\" -> \"%p\" [style=dashed,label=\"1\"];\n", dt.obj, dt.all) +
+		"}\n"
+	if !strings.HasSuffix(out, actions) {
+		t.Errorf("unexpected actions in dot output:\n%s\nwant:\n%s", out, actions)
+	}
+}
+
+func TestDiagrammer_WriteMermaid(t *testing.T) {
+	dt := newDiagramTest(t)
+	var buf bytes.Buffer
+	dia := Diagrammer{RankDir: "LR"}
+	testerr.Shall(dia.WriteMermaid(&buf, dt.prj)).BeNil(t)
+	out := buf.String()
+	if !strings.HasPrefix(out, "flowchart LR\n") {
+		t.Errorf("missing flowchart header:\n%s", out)
+	}
+	expectLines(t, out,
+		fmt.Sprintf("\tg%p[\"File<br/>a.c\"]:::bold\n", dt.src),
+		fmt.Sprintf("\tg%p[\"File<br/>#quot;a#quot;.o\"]\n", dt.obj),
+		fmt.Sprintf("\tg%p[\"all\"]:::abstractBold\n", dt.all),
+		fmt.Sprintf("\ta%p(\"cc\")\n", dt.cc),
+		fmt.Sprintf("\tg%p --> a%p\n", dt.src, dt.cc),
+		fmt.Sprintf("\ta%p -->|\"1\"| g%p\n", dt.cc, dt.obj),
+		fmt.Sprintf("\tg%p -.->|\"1\"| g%p\n", dt.obj, dt.all),
+	)
+}
+
+func TestDiagrammer_WritePlantUML(t *testing.T) {
+	dt := newDiagramTest(t)
+	var buf bytes.Buffer
+	dia := Diagrammer{RankDir: "LR"}
+	testerr.Shall(dia.WritePlantUML(&buf, dt.prj)).BeNil(t)
+	out := buf.String()
+	if !strings.HasPrefix(out, "@startuml \"TestDiagrammer_WritePlantUML\"\nleft to right direction\n") {
+		t.Errorf("missing startuml header:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "@enduml\n") {
+		t.Errorf("missing enduml:\n%s", out)
+	}
+	expectLines(t, out,
+		fmt.Sprintf("file \"File\\na.c\" as g%p #line.bold\n", dt.src),
+		fmt.Sprintf("file \"File\\n&#34;a&#34;.o\" as g%p\n", dt.obj),
+		fmt.Sprintf("rectangle \"all\" as g%p #line.dashed;line.bold\n", dt.all),
+		fmt.Sprintf("card \"cc\" as a%p\n", dt.cc),
+		fmt.Sprintf("g%p --> a%p\n", dt.src, dt.cc),
+		fmt.Sprintf("a%p --> g%p : 1\n", dt.cc, dt.obj),
+		fmt.Sprintf("g%p ..> g%p : 1\n", dt.obj, dt.all),
+	)
+}
+
+func parseGraphML(t *testing.T, data []byte) (id string, nodes, edges map[string]string) {
+	t.Helper()
+	type graphMLData struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	var doc struct {
+		Graph struct {
+			ID    string `xml:"id,attr"`
+			Nodes []struct {
+				ID   string        `xml:"id,attr"`
+				Data []graphMLData `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string        `xml:"source,attr"`
+				Target string        `xml:"target,attr"`
+				Data   []graphMLData `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	testerr.Shall(xml.Unmarshal(data, &doc)).BeNil(t)
+	nodes = make(map[string]string)
+	for _, n := range doc.Graph.Nodes {
+		nodes[n.ID] = fmt.Sprint(n.Data)
+	}
+	edges = make(map[string]string)
+	for _, e := range doc.Graph.Edges {
+		edges[e.Source+" "+e.Target] = fmt.Sprint(e.Data)
+	}
+	return doc.Graph.ID, nodes, edges
+}
+
+func TestDiagrammer_WriteGraphML(t *testing.T) {
+	dt := newDiagramTest(t)
+	var buf bytes.Buffer
+	var dia Diagrammer
+	testerr.Shall(dia.WriteGraphML(&buf, dt.prj)).BeNil(t)
+	id, nodes, edges := parseGraphML(t, buf.Bytes())
+	if id != "TestDiagrammer_WriteGraphML" {
+		t.Errorf("unexpected graph id '%s'", id)
+	}
+	for id, data := range map[string]string{
+		fmt.Sprintf("g%p", dt.src): "[{label a.c} {kind tangible} {artefact File}]",
+		fmt.Sprintf("g%p", dt.obj): `[{label "a".o} {kind tangible} {artefact File}]`,
+		fmt.Sprintf("g%p", dt.all): "[{label all} {kind abstract}]",
+		fmt.Sprintf("a%p", dt.cc):  "[{label cc} {kind action}]",
+	} {
+		if nodes[id] != data {
+			t.Errorf("node %s: want %s, got %s", id, data, nodes[id])
+		}
+	}
+	for st, data := range map[string]string{
+		fmt.Sprintf("g%p a%p", dt.src, dt.cc):  "[]",
+		fmt.Sprintf("a%p g%p", dt.cc, dt.obj):  "[{order 1}]",
+		fmt.Sprintf("g%p g%p", dt.obj, dt.all): "[{implicit true} {order 1}]",
+	} {
+		if edges[st] != data {
+			t.Errorf("edge %s: want %s, got %s", st, data, edges[st])
+		}
+	}
+	if len(edges) != 3 {
+		t.Errorf("unexpected edges %v", edges)
+	}
+}
+
+func TestDiagrammer_Plan(t *testing.T) {
+	prj := gomktest.TempProject(t,
+		gomktest.File{Path: "a.c", MTime: gomktest.Time(2)},
+		gomktest.File{Path: "a.o", MTime: gomktest.Time(1)},
+		gomktest.File{Path: "a.txt", MTime: gomktest.Time(3)},
+	)
+	nop := OpFunc("nop", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error { return nil })
+	var src, obj, txt, all, miss, fail *gomkore.Goal
+	var cc, doc, ld, bad *gomkore.Action
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		s := prj.Goal(mkfs.File("a.c"))
+		o, a := prj.Goal(mkfs.File("a.o")).By(nop, s)
+		x, d := prj.Goal(mkfs.File("a.txt")).By(nop, s)
+		m, l := prj.Goal(mkfs.File("b.o")).By(nop, s)
+		f, b := prj.Goal(mkfs.File("c.o")).By(OpFunc("bad", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			return errors.New("bad")
+		}), s)
+		all = prj.AbstractGoal("all").ImpliedBy(o).Goal()
+		src, obj, txt, miss, fail = s.Goal(), o.Goal(), x.Goal(), m.Goal(), f.Goal()
+		cc, doc, ld, bad = a.Action(), d.Action(), l.Action(), b.Action()
+	})).BeNil(t)
+	if _, err := gomktest.Build(t, prj, nil, "c.o"); err == nil {
+		t.Fatal("no error from failing action")
+	}
+
+	bd := testerr.Shall1(gomkore.NewBuilder(gomkore.NewTrace(context.Background(), gomktest.NewRecorder(t)), nil)).BeNil(t)
+	dia := Diagrammer{Plan: testerr.Shall1(bd.Plan(prj)).BeNil(t)}
+	var buf bytes.Buffer
+	testerr.Shall(dia.WriteGraphML(&buf, prj)).BeNil(t)
+	_, nodes, edges := parseGraphML(t, buf.Bytes())
+	for n, state := range map[string]string{
+		fmt.Sprintf("g%p", src):  "up-to-date",
+		fmt.Sprintf("g%p", obj):  "outdated",
+		fmt.Sprintf("g%p", txt):  "up-to-date",
+		fmt.Sprintf("g%p", all):  "outdated",
+		fmt.Sprintf("g%p", miss): "missing",
+		fmt.Sprintf("g%p", fail): "failed",
+		fmt.Sprintf("a%p", cc):   "outdated",
+		fmt.Sprintf("a%p", doc):  "up-to-date",
+		fmt.Sprintf("a%p", ld):   "outdated",
+		fmt.Sprintf("a%p", bad):  "failed",
+	} {
+		if !strings.Contains(nodes[n], "{state "+state+"}") {
+			t.Errorf("node %s: want state %s, got %s", n, state, nodes[n])
+		}
+	}
+	var triggers []string
+	for st, data := range edges {
+		if strings.Contains(data, "{trigger true}") {
+			triggers = append(triggers, st)
+		}
+	}
+	slices.Sort(triggers)
+	expect := []string{
+		fmt.Sprintf("g%p a%p", src, cc),
+		fmt.Sprintf("a%p g%p", cc, obj),
+		fmt.Sprintf("g%p g%p", obj, all),
+		fmt.Sprintf("a%p g%p", ld, miss),
+		fmt.Sprintf("a%p g%p", bad, fail),
+	}
+	slices.Sort(expect)
+	if !slices.Equal(triggers, expect) {
+		t.Errorf("unexpected trigger edges:\n%v\nwant:\n%v", triggers, expect)
+	}
+
+	buf.Reset()
+	testerr.Shall(dia.WriteDot(&buf, prj)).BeNil(t)
+	expectLines(t, buf.String(),
+		fmt.Sprintf("\t\"%p\" [shape=record,style=\"filled\",fillcolor=\"gold\",label=\"{File|a.o}\"];\n", obj),
+		fmt.Sprintf("\t\"%p\" -> \"%p\" [color=\"red\",penwidth=2];\n", src, cc),
+		fmt.Sprintf("\t\"%p\" -> \"%p\";\n", src, doc),
+	)
+}
+
+func TestDiagrammer_Plan_restoredState(t *testing.T) {
+	dir := gomktest.TempProject(t, gomktest.File{Path: "a.c"}).Dir
+	newPrj := func() (prj *gomkore.Project, obj *gomkore.Goal, cc *gomkore.Action) {
+		prj = gomkore.NewProject(dir)
+		prj.State = testerr.Shall1(gomkore.OpenState(prj, "")).BeNil(t)
+		testerr.Shall(Edit(prj, func(prj ProjectEd) {
+			o, a := prj.Goal(mkfs.File("a.o")).By(OpFunc("bad", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+				return errors.New("bad")
+			}), prj.Goal(mkfs.File("a.c")))
+			obj, cc = o.Goal(), a.Action()
+		})).BeNil(t)
+		return prj, obj, cc
+	}
+	prj, _, _ := newPrj()
+	if _, err := gomktest.Build(t, prj, nil); err == nil {
+		t.Fatal("no error from failing action")
+	}
+
+	// A new project stands for the next run of the build script
+	prj, obj, cc := newPrj()
+	bd := testerr.Shall1(gomkore.NewBuilder(gomkore.NewTrace(context.Background(), gomktest.NewRecorder(t)), nil)).BeNil(t)
+	dia := Diagrammer{Plan: testerr.Shall1(bd.Plan(prj)).BeNil(t)}
+	var buf bytes.Buffer
+	testerr.Shall(dia.WriteGraphML(&buf, prj)).BeNil(t)
+	_, nodes, _ := parseGraphML(t, buf.Bytes())
+	for _, n := range []string{fmt.Sprintf("g%p", obj), fmt.Sprintf("a%p", cc)} {
+		if !strings.Contains(nodes[n], "{state failed}") {
+			t.Errorf("node %s not failed: %s", n, nodes[n])
+		}
+	}
+}
@@ -2,6 +2,7 @@ package gomk
 
 import (
 	"io/fs"
+	"time"
 
 	"git.fractalqb.de/fractalqb/eloc/must"
 	"git.fractalqb.de/fractalqb/gomk/gomkore"
@@ -33,6 +34,18 @@ func (ed ProjectEd) AbstractGoal(name string) GoalEd {
 	return ed.Goal(gomkore.Abstract(name))
 }
 
+// SubProject adds sub as a sub-project goal to ed's project, see
+// [gomkore.Project.SubProject].
+func (ed ProjectEd) SubProject(sub *gomkore.Project) GoalEd {
+	return GoalEd{must.Ret(ed.p.SubProject(sub))}
+}
+
+// SubGoal returns the goal of ed's project that stands for goal g of a
+// sub-project, see [gomkore.Project.SubGoal].
+func (ed ProjectEd) SubGoal(g GoalEd) GoalEd {
+	return GoalEd{must.Ret(ed.p.SubGoal(g.g))}
+}
+
 func (ed ProjectEd) RelPath(p string) string {
 	rp, err := ed.p.RelPath(p)
 	if err != nil {
@@ -74,6 +87,14 @@ func (ed GoalEd) SetUpdateMode(m gomkore.UpdateMode) { ed.g.UpdateMode = m }
 func (ed GoalEd) Removable() bool        { return ed.g.Removable }
 func (ed GoalEd) SetRemovable(flag bool) { ed.g.Removable = flag }
 
+func (ed GoalEd) Labels() gomkore.Labels { return ed.g.Labels }
+
+// AddLabels adds labels to the goal, see [gomkore.Labels].
+func (ed GoalEd) AddLabels(ls ...string) GoalEd {
+	ed.g.Labels.Add(ls...)
+	return ed
+}
+
 func (ed GoalEd) Artefact() gomkore.Artefact { return ed.g.Artefact }
 
 func (ed GoalEd) IsAbstract() bool { return ed.g.IsAbstract() }
@@ -120,3 +141,23 @@ func (ed ActionEd) Project() ProjectEd {
 func (ed ActionEd) SetIgnoreError(ignore bool) {
 	ed.a.IgnoreError = ignore
 }
+
+// SetTimeout sets the timeout for the action's operation, see
+// [gomkore.Action.Timeout].
+func (ed ActionEd) SetTimeout(d time.Duration) {
+	ed.a.Timeout = d
+}
+
+// SetRetry sets the retry policy of the action, see [gomkore.RetryPolicy].
+func (ed ActionEd) SetRetry(p *gomkore.RetryPolicy) {
+	ed.a.Retry = p
+}
+
+func (ed ActionEd) Labels() gomkore.Labels { return ed.a.Labels }
+
+// AddLabels adds labels to the action, see [gomkore.Labels]. Goals inherit the
+// labels of the actions that result in them for label selection.
+func (ed ActionEd) AddLabels(ls ...string) ActionEd {
+	ed.a.Labels.Add(ls...)
+	return ed
+}
@@ -4,6 +4,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"log/slog"
 	"os"
@@ -28,21 +29,50 @@ var (
 	// Some options (See also: https://pkg.go.dev/codeberg.org/fractalqb/gomklib#GoModule)
 	clean, dryrun bool
 	writeDot      bool
+	diagram       string
+	diagramState  bool
 	offline       bool
+	jobs          int
+	keepGoing     bool
+	labels        string
+	reportFile    string
+	timelineFile  string
+	progress      bool
+	logDir        string
 )
 
 func flags() {
 	flag.BoolVar(&writeDot, "dot", writeDot, "Write graphviz file to stdout and exit")
+	flag.StringVar(&diagram, "diagram", diagram, "Write diagram in format dot|mermaid|puml|graphml to stdout and exit")
+	flag.BoolVar(&diagramState, "diagram-state", diagramState, "Show what a build would update in the diagram")
 	flag.BoolVar(&clean, "clean", clean, "Clean project")
 	flag.BoolVar(&dryrun, "n", dryrun, "Dryrun")
 	flag.BoolVar(&offline, "offline", offline, "Skip everything that requires being online")
+	flag.IntVar(&jobs, "j", jobs, "Maximum number of concurrent jobs")
+	flag.BoolVar(&keepGoing, "k", keepGoing, "Keep going after errors")
+	flag.StringVar(&labels, "l", labels, "Select goals by label expression, e.g. 'docs | test'")
+	flag.StringVar(&reportFile, "report", reportFile, "Write JSON build report to file")
+	flag.StringVar(&timelineFile, "timeline", timelineFile, "Write Chrome trace events to file")
+	flag.StringVar(&logDir, "logs", logDir, "Write output of each action to a log file in dir")
+	flag.BoolVar(&progress, "progress", progress, "Show live progress on terminal")
 	fTrace := flag.String("trace", "", "Set trace level")
+	fOutput := flag.String("output", "", gomk.WriteTraceOutputFlagDoc)
 	flag.Parse()
 
 	tracer.ParseLevelFlag(*fTrace)
+	tracer.ParseOutputFlag(*fOutput)
+	if writeDot {
+		diagram = "dot"
+	}
 }
 
 func main() {
+	var failed bool
+	defer func() {
+		if failed {
+			os.Exit(1)
+		}
+	}()
 	flags()
 
 	// The project in current working dir
@@ -55,6 +85,7 @@ func main() {
 
 		goalTest, _ := prj.AbstractGoal("test").
 			By(&goTest, goalGoGen)
+		goalTest.AddLabels("test")
 
 		goalPkgFoo := prj.Goal(mkfs.DirFiles("cmd/foo", "", 1)).
 			ImpliedBy(goalTest)
@@ -89,6 +120,7 @@ func main() {
 		)
 		for _, g := range goals {
 			g.SetRemovable(true)
+			g.AddLabels("docs")
 		}
 		goalDoc := prj.Goal(gomkore.Abstract("doc")).ImpliedBy(goals...)
 		goalDoc.SetUpdateMode(gomk.UpdAllActions | gomk.UpdUnordered)
@@ -113,25 +145,89 @@ func main() {
 		)
 		for _, g := range goals {
 			g.SetRemovable(true)
+			g.AddLabels("docs")
 		}
 		goalDoc.ImpliedBy(goals...)
 	})
 	if err != nil {
 		log.Fatal("editing project:", err)
 	}
-	tr := gomkore.NewTrace(context.Background(), tracer)
+	// Remember failures between runs, e.g. for -diagram-state
+	if prj.State, err = gomkore.OpenState(prj, ""); err != nil {
+		log.Fatal(err)
+	}
+	if err := prj.Validate(); err != nil {
+		log.Fatal("invalid project:", err)
+	}
+	ctx, stop := gomk.SignalContext(context.Background())
+	defer stop()
+	var base gomkore.Tracer = tracer
+	if progress {
+		pt := gomk.NewProgressTracer(os.Stderr, tracer.Log)
+		pt.Output = tracer.Output
+		defer pt.Close()
+		base = pt
+	}
+	tracers := []gomkore.Tracer{base}
+	if timelineFile != "" {
+		w, err := os.Create(timelineFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer w.Close()
+		timeline := gomk.NewTimelineTracer(w)
+		defer timeline.Close()
+		tracers = append(tracers, timeline)
+	}
+	var logs *gomk.LogFileTracer
+	if logDir != "" {
+		logs = gomk.NewLogFileTracer(logDir)
+		tracers = append(tracers, logs)
+	}
+	var tr *gomkore.Trace
+	if len(tracers) > 1 {
+		tr = gomkore.NewTrace(ctx, gomk.NewMultiTracer(tracers...))
+	} else {
+		tr = gomkore.NewTrace(ctx, base)
+	}
 
 	if clean {
-		err := gomk.Clean(prj, dryrun, tr)
+		if labels != "" {
+			err = gomk.CleanLabeled(prj, labels, dryrun, tr)
+		} else {
+			err = gomk.Clean(prj, dryrun, tr)
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
 		return
 	}
 
-	if writeDot {
+	if diagram != "" {
 		dia := gomk.Diagrammer{RankDir: "LR"}
-		if err := dia.WriteDot(os.Stdout, prj); err != nil {
+		if labels != "" {
+			if dia.Select, err = gomkore.ParseLabelExpr(labels); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if diagramState {
+			if dia.Plan, err = gomk.NewBuilder(tr, nil).Plan(prj); err != nil {
+				log.Fatal(err)
+			}
+		}
+		switch diagram {
+		case "dot":
+			err = dia.WriteDot(os.Stdout, prj)
+		case "mermaid":
+			err = dia.WriteMermaid(os.Stdout, prj)
+		case "puml":
+			err = dia.WritePlantUML(os.Stdout, prj)
+		case "graphml":
+			err = dia.WriteGraphML(os.Stdout, prj)
+		default:
+			err = fmt.Errorf("unknown diagram format '%s'", diagram)
+		}
+		if err != nil {
 			slog.Error(err.Error())
 			os.Exit(1)
 		}
@@ -139,13 +235,50 @@ func main() {
 	}
 
 	build := gomk.NewBuilder(tr, nil)
-	if flag.NArg() == 0 {
-		if err := build.Project(prj); err != nil {
-			slog.Error(err.Error())
+	build.MaxJobs = jobs
+	build.KeepGoing = keepGoing
+	if dryrun {
+		var plan *gomkore.Plan
+		switch {
+		case labels != "":
+			plan, err = build.PlanLabeledGoals(prj, labels)
+		case flag.NArg() == 0:
+			plan, err = build.Plan(prj)
+		default:
+			plan, err = build.PlanNamedGoals(prj, flag.Args()...)
 		}
-	} else {
-		if err := build.NamedGoals(prj, flag.Args()...); err != nil {
+		if err != nil {
 			slog.Error(err.Error())
+			os.Exit(1)
+		}
+		for _, step := range plan.Steps {
+			fmt.Println(step)
+		}
+		return
+	}
+	switch {
+	case labels != "":
+		err = build.LabeledGoals(prj, labels)
+	case flag.NArg() == 0:
+		err = build.Project(prj)
+	default:
+		err = build.NamedGoals(prj, flag.Args()...)
+	}
+	if err != nil {
+		failed = true
+		slog.Error(err.Error())
+		if logs != nil {
+			logs.WriteFailures(os.Stderr, err)
+		}
+	}
+	if rep := build.Report(); rep != nil && reportFile != "" {
+		w, err := os.Create(reportFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer w.Close()
+		if err := rep.WriteJSON(w); err != nil {
+			log.Fatal(err)
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package gomk
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"hash"
@@ -10,11 +11,17 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"git.fractalqb.de/fractalqb/gomk/gomkore"
 	"git.fractalqb.de/fractalqb/gomk/mkfs"
 )
 
+// DefaultKillGrace is the default for [CmdOp.KillGrace].
+const DefaultKillGrace = 5 * time.Second
+
+// CmdOp runs an external command. When the context of the trace is done, the
+// command is killed, see also [CmdOp.ProcGroup].
 type CmdOp struct {
 	CWD             string
 	Exe             string
@@ -22,6 +29,18 @@ type CmdOp struct {
 	InFile, OutFile string
 	Desc            string
 	UsesEnv         []string
+
+	// KillGrace is the time to wait after terminating a cancelled command
+	// before it is killed. If 0, DefaultKillGrace is used.
+	KillGrace time.Duration
+
+	// ProcGroup runs the command in its own process group on Unix systems if
+	// the context of the trace can be cancelled. When the context is done,
+	// the whole process group is terminated with SIGTERM and killed with
+	// SIGKILL after KillGrace. Such commands cannot read from the terminal
+	// and do not get signals like SIGINT from the terminal, so use ProcGroup
+	// with a trace context from [SignalContext].
+	ProcGroup bool
 }
 
 var _ gomkore.Operation = (*CmdOp)(nil)
@@ -43,6 +62,7 @@ func (op *CmdOp) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env) erro
 		tr.Warn(err.Error(), slog.String("action", a.String()))
 	}
 	cmd := exec.CommandContext(tr.Ctx(), op.Exe, op.Args...)
+	waited := op.cancel(tr.Ctx(), cmd)
 	cmd.Dir = op.CWD
 	cmd.Env = xenv
 	if op.InFile != "" {
@@ -71,12 +91,30 @@ func (op *CmdOp) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env) erro
 		slog.String("dir", cmd.Dir),
 	)
 	err = cmd.Run()
+	waited()
 	if err != nil {
 		return fmt.Errorf("command %s in %s failed: %w", cmd, cmd.Dir, err)
 	}
 	return err
 }
 
+// cancel sets up how cmd is stopped when ctx is done, see [CmdOp.ProcGroup].
+// The returned function must be called once cmd was waited for.
+func (op *CmdOp) cancel(ctx context.Context, cmd *exec.Cmd) (waited func()) {
+	if !op.ProcGroup || ctx.Done() == nil {
+		cmd.WaitDelay = op.killGrace()
+		return func() {}
+	}
+	return cancelProcGroup(cmd, op.killGrace())
+}
+
+func (op *CmdOp) killGrace() time.Duration {
+	if op.KillGrace > 0 {
+		return op.KillGrace
+	}
+	return DefaultKillGrace
+}
+
 // WriteHash considers env only if op.UsesEnv is set correctly.
 func (op *CmdOp) WriteHash(h hash.Hash, a *gomkore.Action, env *gomkore.Env) (bool, error) {
 	fmt.Fprintln(h, op.CWD)
@@ -114,15 +152,24 @@ func (po PipeOp) Describe(a *gomkore.Action, env *gomkore.Env) string {
 func (po PipeOp) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env) error {
 	var (
 		cmds      = make([]*exec.Cmd, len(po))
+		waited    = make([]func(), len(po))
 		pipes     = make([]piperw, len(po)-1)
 		xenv, err = env.ExecEnv()
 	)
 	if err != nil {
 		tr.Warn(err.Error(), slog.String("action", a.String()))
 	}
+	defer func() {
+		for _, w := range waited {
+			if w != nil {
+				w()
+			}
+		}
+	}()
 	for i := 0; i < len(po); i++ {
 		cop := &po[i]
 		cmd := exec.CommandContext(tr.Ctx(), cop.Exe, cop.Args...)
+		waited[i] = cop.cancel(tr.Ctx(), cmd)
 		cmd.Dir = cop.CWD
 		cmd.Env = xenv
 		if i == 0 {
@@ -150,7 +197,9 @@ func (po PipeOp) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env) erro
 		}
 	}
 	for i, cmd := range cmds {
-		if err := cmd.Wait(); err != nil {
+		err := cmd.Wait()
+		waited[i]()
+		if err != nil {
 			for k := i + 1; k < len(cmds); k++ {
 				if e := cmds[k].Process.Kill(); e != nil {
 					tr.Warn("aborting pipe with `error`", `error`, e)
@@ -300,6 +349,16 @@ func (cc *ConvertCmd) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env)
 	return op.Do(tr, a, env)
 }
 
-func (cc *ConvertCmd) WriteHash(hash.Hash, *gomkore.Action, *gomkore.Env) (bool, error) {
-	return false, errors.New("NYI: ConvertCmd.WriteHash()")
+func (cc *ConvertCmd) WriteHash(h hash.Hash, a *gomkore.Action, env *gomkore.Env) (bool, error) {
+	fmt.Fprintln(h, "gomk.ConvertCmd", cc.Exe, cc.PassOut, cc.OutRelToIn, cc.OutDir)
+	fmt.Fprintln(h, "args", len(cc.Args))
+	for _, arg := range cc.Args {
+		fmt.Fprintln(h, arg)
+	}
+	if a != nil {
+		for _, res := range a.Results() {
+			fmt.Fprintln(h, res.Name())
+		}
+	}
+	return cc.MkDirs.WriteHash(h, a, env)
 }
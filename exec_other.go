@@ -0,0 +1,16 @@
+//go:build !unix
+
+package gomk
+
+import (
+	"os/exec"
+	"time"
+)
+
+// cancelProcGroup kills only the process of cmd when the command's context is
+// done. Waiting for the command's output ends after grace. The returned
+// function has to be called once cmd was waited for.
+func cancelProcGroup(cmd *exec.Cmd, grace time.Duration) (waited func()) {
+	cmd.WaitDelay = grace
+	return func() {}
+}
@@ -0,0 +1,63 @@
+//go:build unix
+
+package gomk
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// killProcGroup sends sig to the process group pgid. Tests replace it to
+// observe the signals sent.
+var killProcGroup = func(pgid int, sig syscall.Signal) error {
+	return syscall.Kill(-pgid, sig)
+}
+
+// cancelProcGroup runs cmd in its own process group, keeping other attributes
+// of cmd.SysProcAttr. When the command's
+// context is done, the whole group is terminated and killed after grace. The
+// returned function must be called once cmd was waited for. It stops the kill
+// timer, because the group ID may be reused after the process was reaped.
+func cancelProcGroup(cmd *exec.Cmd, grace time.Duration) (waited func()) {
+	var (
+		mu    sync.Mutex
+		done  bool
+		timer *time.Timer
+	)
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		pgid := cmd.Process.Pid
+		mu.Lock()
+		if !done {
+			timer = time.AfterFunc(grace, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if !done {
+					killProcGroup(pgid, syscall.SIGKILL)
+				}
+			})
+		}
+		mu.Unlock()
+		err := killProcGroup(pgid, syscall.SIGTERM)
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+	cmd.WaitDelay = grace
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done = true
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
@@ -0,0 +1,121 @@
+//go:build unix
+
+package gomk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+func TestCmdOp_cancelProcGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	op := CmdOp{
+		Exe:       "sh",
+		Args:      []string{"-c", "sleep 30 & echo $! > " + pidFile + "; wait"},
+		KillGrace: 100 * time.Millisecond,
+		ProcGroup: true,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out strings.Builder
+	env := gomkore.Env{Out: &out, Err: &out}
+	time.AfterFunc(200*time.Millisecond, cancel)
+	start := time.Now()
+	if err := op.Do(gomkore.NewTrace(ctx, TestTracer{t}), nil, &env); err == nil {
+		t.Fatal("no error from cancelled command")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("cancelled command took %s", d)
+	}
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); procAlive(pid); {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("child process %d survived cancellation", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCmdOp_cancelProcGroup_noLateKill(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sigs []syscall.Signal
+	)
+	defer func(kill func(int, syscall.Signal) error) { killProcGroup = kill }(killProcGroup)
+	killProcGroup = func(pgid int, sig syscall.Signal) error {
+		mu.Lock()
+		sigs = append(sigs, sig)
+		mu.Unlock()
+		return syscall.Kill(-pgid, sig)
+	}
+	op := CmdOp{
+		Exe:       "sleep",
+		Args:      []string{"30"},
+		KillGrace: 100 * time.Millisecond,
+		ProcGroup: true,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out strings.Builder
+	env := gomkore.Env{Out: &out, Err: &out}
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if err := op.Do(gomkore.NewTrace(ctx, TestTracer{t}), nil, &env); err == nil {
+		t.Fatal("no error from cancelled command")
+	}
+	time.Sleep(3 * op.KillGrace)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sigs) != 1 || sigs[0] != syscall.SIGTERM {
+		t.Errorf("unexpected signals %v", sigs)
+	}
+}
+
+func TestCmdOp_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, c := range []struct {
+		group bool
+		ctx   context.Context
+		pgid  bool
+	}{
+		{false, ctx, false},
+		{true, context.Background(), false},
+		{true, ctx, true},
+	} {
+		op := CmdOp{ProcGroup: c.group}
+		cmd := exec.Command("true")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Noctty: true}
+		op.cancel(c.ctx, cmd)()
+		if cmd.SysProcAttr.Setpgid != c.pgid || !cmd.SysProcAttr.Noctty {
+			t.Errorf("group=%t: unexpected process attributes %+v", c.group, cmd.SysProcAttr)
+		}
+	}
+}
+
+func procAlive(pid int) bool {
+	if stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		i := bytes.LastIndexByte(stat, ')')
+		return i < 0 || i+2 >= len(stat) || stat[i+2] != 'Z'
+	}
+	return syscall.Kill(pid, 0) == nil
+}
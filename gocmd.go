@@ -115,8 +115,10 @@ func (gb *GoBuild) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env) er
 	return op.Do(tr, a, env)
 }
 
+// WriteHash cannot hash GoBuild because the sources of the built packages,
+// their dependencies and go.mod are not taken from the action's premises.
 func (*GoBuild) WriteHash(hash.Hash, *gomkore.Action, *gomkore.Env) (bool, error) {
-	return false, errors.New("NYI: GoBuild.WriteHash()")
+	return false, nil
 }
 
 type GoTest struct {
@@ -150,8 +152,10 @@ func (gt *GoTest) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env) err
 	return op.Do(tr, a, env)
 }
 
+// WriteHash cannot hash GoTest because its sources are not taken from the
+// action's premises.
 func (*GoTest) WriteHash(hash.Hash, *gomkore.Action, *gomkore.Env) (bool, error) {
-	return false, errors.New("NYI: GoTest.WriteHash()")
+	return false, nil
 }
 
 type GoGenerate struct {
@@ -196,8 +200,10 @@ func (gg *GoGenerate) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env)
 	return op.Do(tr, a, env)
 }
 
+// WriteHash cannot hash GoGenerate because its sources are not taken from the
+// action's premises.
 func (*GoGenerate) WriteHash(hash.Hash, *gomkore.Action, *gomkore.Env) (bool, error) {
-	return false, errors.New("NYI: GoGenerate.WriteHash()")
+	return false, nil
 }
 
 type GoRun struct {
@@ -241,6 +247,8 @@ func (gr *GoRun) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env) erro
 	return op.Do(tr, a, env)
 }
 
+// WriteHash cannot hash GoRun because its sources are not taken from the
+// action's premises.
 func (*GoRun) WriteHash(hash.Hash, *gomkore.Action, *gomkore.Env) (bool, error) {
-	return false, errors.New("NYI: GoRun.WriteHash()")
+	return false, nil
 }
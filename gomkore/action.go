@@ -1,8 +1,12 @@
 package gomkore
 
 import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"hash"
 	"sync/atomic"
+	"time"
 )
 
 type Operation interface {
@@ -19,13 +23,28 @@ type Operation interface {
 type Action struct {
 	Op          Operation
 	IgnoreError bool
+	Labels      Labels
+
+	// Timeout cancels the context of the operation's trace after the given
+	// duration if > 0. The action then fails with [ErrActionTimeout].
+	Timeout time.Duration
+
+	// Retry runs the operation again after it failed if not nil.
+	Retry *RetryPolicy
 
 	prj      *Project
 	premises []*Goal
 	results  []*Goal
 
-	lockGID uintptr
-	lastBID BuildID
+	lockGID  uintptr
+	lastBID  BuildID
+	lastErr  error
+	lastOut  []byte
+	hash     []byte
+	nextHash []byte
+	doneBID  BuildID
+	doneAt   time.Time
+	doneDur  time.Duration
 }
 
 func (a *Action) Project() *Project   { return a.prj }
@@ -36,17 +55,28 @@ func (a *Action) Result(i int) *Goal  { return a.results[i] }
 
 func (a *Action) LastBuild() BuildID { return a.lastBID }
 
+// LastError returns the error of a's operation in the last build, even if it
+// was ignored, see [Action.IgnoreError]. Before a runs for the first time, the
+// error is restored from the [State] of its project, if any.
+func (a *Action) LastError() error { return a.lastErr }
+
+// LastHash returns the fingerprint of a's last successful run, if known. See
+// also [Action.Fingerprint].
+func (a *Action) LastHash() []byte { return a.hash }
+
 // Must not run concurrently, see [Goal.LockPreActions] and [tryLock]
 func (a *Action) Run(tr *Trace, env *Env) (BuildID, error) {
 	if tr.Build() <= a.lastBID {
 		return a.lastBID, nil
 	}
-	a.lastBID = tr.Build()
+	a.lastBID, a.lastErr, a.lastOut = tr.Build(), nil, nil
 	if env == nil {
 		env = DefaultEnv(tr)
 	}
+	tr = tr.pushAction(a)
 	if a.Op == nil {
 		tr.runImplicitAction(a)
+		tr.actionDone(a, 0, nil)
 		return 0, nil
 	}
 	var err error
@@ -56,11 +86,25 @@ func (a *Action) Run(tr *Trace, env *Env) (BuildID, error) {
 	}
 	defer tr.closeActionEnv(env)
 	tr.runAction(a)
-	err = a.Op.Do(tr, a, env)
+	before := a.resultStates()
+	start := time.Now()
+	err = a.do(tr, env)
+	tr.actionDone(a, time.Since(start), err)
+	a.lastErr = err
+	if c, ok := env.Out.(OutputCapture); ok && err != nil {
+		a.lastOut = c.CapturedOutput()
+	}
+	if err != nil && (tr.Ctx().Err() != nil || errors.Is(err, ErrActionTimeout)) {
+		a.removePartial(tr, before)
+	}
 	switch {
 	case err == nil:
+		if a.nextHash != nil {
+			a.hash, a.nextHash = a.nextHash, nil
+		}
+		a.doneBID, a.doneAt, a.doneDur = a.lastBID, a.Project().Now(), time.Since(start)
 		return 0, nil
-	case a.IgnoreError:
+	case a.IgnoreError && tr.Ctx().Err() == nil:
 		tr.Warn("ignoring `action` `error`",
 			`action`, a,
 			`error`, err,
@@ -70,6 +114,37 @@ func (a *Action) Run(tr *Trace, env *Env) (BuildID, error) {
 	return 0, err
 }
 
+// ActionError is returned from builds for an action that failed.
+type ActionError struct {
+	Action *Action
+	// Goal is the goal that was updated when the action failed.
+	Goal *Goal
+	// Path lists the names of the goals that lead to the action, see
+	// [Trace.GoalPath].
+	Path string
+	Err  error
+
+	// Output is the output of the failed action if it was captured, see
+	// [OutputCapture].
+	Output []byte
+}
+
+// OutputCapture is implemented by writers that keep the output of an action,
+// e.g. to print it as one block after the action finished. If the Out writer
+// of the environment set up for an action by [TracerCommon.SetupActionEnv]
+// implements OutputCapture, the output of a failed action is attached to its
+// [ActionError].
+type OutputCapture interface {
+	// CapturedOutput returns everything written to Out and Err of the action.
+	CapturedOutput() []byte
+}
+
+func (e *ActionError) Error() string {
+	return fmt.Sprintf("action (%s) for %s failed: %s", e.Action, e.Path, e.Err)
+}
+
+func (e *ActionError) Unwrap() error { return e.Err }
+
 func (a *Action) String() string {
 	switch {
 	case a == nil:
@@ -87,6 +162,22 @@ func (a *Action) WriteHash(h hash.Hash, env *Env) (bool, error) {
 	return a.Op.WriteHash(h, a, env)
 }
 
+// Fingerprint computes a digest from a's operation and the content of all its
+// premises. It returns nil if a has no operation, the operation cannot be
+// hashed or any premise is not a hashable artefact.
+func (a *Action) Fingerprint(env *Env) ([]byte, error) {
+	h := sha256.New()
+	if ok, err := a.WriteHash(h, env); !ok || err != nil {
+		return nil, err
+	}
+	for _, pre := range a.Premises() {
+		if ok, err := pre.WriteHash(h); !ok || err != nil {
+			return nil, err
+		}
+	}
+	return h.Sum(nil), nil
+}
+
 func (a *Action) tryLock(byGID uintptr) (blockingGID uintptr) {
 	if atomic.CompareAndSwapUintptr(&a.lockGID, 0, byGID) {
 		return 0
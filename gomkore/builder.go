@@ -4,12 +4,36 @@ import (
 	"errors"
 	"fmt"
 	"hash"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
-// TODO Add a "dry-run" option
 type Builder struct {
 	updater
+
+	// MaxJobs is the maximum number of actions that are run concurrently.
+	// Independent premises of a goal and independent leafs of a project are
+	// then built in parallel. The order of actions of ordered update modes is
+	// kept. With MaxJobs < 2 everything is built sequentially. Sub-projects
+	// built by a Builder operation share the job slots of the parent build.
+	MaxJobs int
+
+	// Fingerprints enables content-based update checks with
+	// [Goal.CheckPreHashes] instead of [Goal.CheckPreTimes].
+	Fingerprints bool
+
+	// KeepGoing continues to build all goals that do not depend on a failed
+	// goal, like make -k. Goals that depend on failed goals are skipped. The
+	// errors of all failed goals are joined into the returned error.
+	KeepGoing bool
+
+	failMu sync.Mutex
+	failed map[*Goal]bool
+	fails  []error
+
+	parent *updater // of the build that runs bd's sub-build
 }
 
 var _ Operation = (*Builder)(nil)
@@ -28,15 +52,35 @@ func NewBuilder(tr *Trace, env *Env) (*Builder, error) {
 
 // Project builds all leafs in prj.
 func (bd *Builder) Project(prj *Project) error {
+	if bd.env == nil {
+		bd.env = DefaultEnv(bd.trace)
+	}
+	return bd.project(bd.trace, prj)
+}
+
+func (bd *Builder) project(tr *Trace, prj *Project) (err error) {
 	bd.bid = prj.LockBuild()
 	defer prj.Unlock()
+	bd.start()
+	bd.restoreState(prj)
+	defer func() {
+		err = errors.Join(err, bd.saveState(prj))
+		bd.reportDone(err)
+	}()
+	if err = bd.buildPrj(tr, prj); err == nil {
+		err = errors.Join(bd.fails...)
+	}
+	return bd.interruption(tr, err)
+}
+
+func (bd *Builder) Goals(gs ...*Goal) (err error) {
 	if bd.env == nil {
 		bd.env = DefaultEnv(bd.trace)
 	}
-	return bd.buildPrj(bd.trace, prj)
+	return bd.goals(bd.trace, gs)
 }
 
-func (bd *Builder) Goals(gs ...*Goal) error {
+func (bd *Builder) goals(tr *Trace, gs []*Goal) (err error) {
 	if len(gs) == 0 {
 		return nil
 	}
@@ -45,107 +89,355 @@ func (bd *Builder) Goals(gs ...*Goal) error {
 		prjStart time.Time
 	)
 	defer func() {
+		err = bd.interruption(tr, err)
 		if prj != nil {
-			bd.trace.doneProject(prj, "building", time.Since(prjStart))
+			err = errors.Join(err, bd.saveState(prj))
+			tr.doneProject(prj, bd.activity("building"), time.Since(prjStart))
 			prj.Unlock()
 		}
+		bd.reportDone(err)
 	}()
-	for _, g := range gs {
-		if p := g.Project(); p != prj {
+	bd.start()
+	for len(gs) > 0 {
+		if p := gs[0].Project(); p != prj {
 			if prj != nil {
-				bd.trace.doneProject(prj, "building", time.Since(prjStart))
+				if err := bd.saveState(prj); err != nil {
+					return err
+				}
+				tr.doneProject(prj, bd.activity("building"), time.Since(prjStart))
 				prj.Unlock()
 			}
 			prj = p
-			bd.trace.startProject(prj, "building")
+			tr.startProject(prj, bd.activity("building"))
 			prjStart = time.Now()
-			if bd.env == nil {
-				bd.env = DefaultEnv(bd.trace)
-			}
-			prj.LockBuild()
+			bd.bid = prj.LockBuild()
+			bd.restoreState(prj)
+		}
+		n := 1
+		for n < len(gs) && gs[n].Project() == prj {
+			n++
 		}
-		if err := bd.buildGoal(bd.trace, g); err != nil {
+		err = bd.jobs.each(n, func(i int) error {
+			return bd.buildGoal(tr, gs[i])
+		})
+		if err != nil {
 			return err
 		}
+		gs = gs[n:]
 	}
-	return nil
+	return errors.Join(bd.fails...)
 }
 
+// Plan returns the actions that [Builder.Project] would run to build prj,
+// without running them.
+func (bd *Builder) Plan(prj *Project) (*Plan, error) {
+	pl := newPlan()
+	bd.plan = pl
+	defer func() { bd.plan = nil }()
+	err := bd.Project(prj)
+	return pl, err
+}
+
+// PlanGoals returns the actions that [Builder.Goals] would run to build gs,
+// without running them.
+func (bd *Builder) PlanGoals(gs ...*Goal) (*Plan, error) {
+	pl := newPlan()
+	bd.plan = pl
+	defer func() { bd.plan = nil }()
+	err := bd.Goals(gs...)
+	return pl, err
+}
+
+// NamedGoals builds the goals of prj that match names, see
+// [Project.FindGoals].
 func (bd *Builder) NamedGoals(prj *Project, names ...string) error {
-	var gs []*Goal
-	for _, n := range names {
-		g := prj.FindGoal(n)
-		if g == nil {
-			return fmt.Errorf("no goal named '%s' in project '%s'", n, prj.String())
-		}
-		gs = append(gs, g)
+	gs, err := prj.FindGoals(names...)
+	if err != nil {
+		return err
 	}
 	return bd.Goals(gs...)
 }
 
+// PlanNamedGoals returns the actions that [Builder.NamedGoals] would run,
+// without running them.
+func (bd *Builder) PlanNamedGoals(prj *Project, names ...string) (*Plan, error) {
+	gs, err := prj.FindGoals(names...)
+	if err != nil {
+		return nil, err
+	}
+	return bd.PlanGoals(gs...)
+}
+
+// LabeledGoals builds the goals of prj that match the label expression expr,
+// see [ParseLabelExpr]. Premises of the selected goals are built as needed,
+// independent of their labels.
+func (bd *Builder) LabeledGoals(prj *Project, expr string) error {
+	gs, err := labeledGoals(prj, expr)
+	if err != nil {
+		return err
+	}
+	return bd.Goals(gs...)
+}
+
+// PlanLabeledGoals returns the actions that [Builder.LabeledGoals] would run,
+// without running them.
+func (bd *Builder) PlanLabeledGoals(prj *Project, expr string) (*Plan, error) {
+	gs, err := labeledGoals(prj, expr)
+	if err != nil {
+		return nil, err
+	}
+	return bd.PlanGoals(gs...)
+}
+
+func labeledGoals(prj *Project, expr string) ([]*Goal, error) {
+	lx, err := ParseLabelExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	gs := prj.SelectGoals(lx)
+	if len(gs) == 0 {
+		return nil, fmt.Errorf("no goal with labels '%s' in project '%s'", lx, prj.String())
+	}
+	return gs, nil
+}
+
 func (bd *Builder) buildPrj(tr *Trace, prj *Project) error {
 	start := time.Now()
 	tr = tr.pushProject(prj)
-	tr.startProject(prj, "building")
+	tr.startProject(prj, bd.activity("building"))
 	leafs := prj.Leafs()
-	for _, leaf := range leafs {
-		if err := bd.buildGoal(tr, leaf); err != nil {
-			return err
+	err := bd.jobs.each(len(leafs), func(i int) error {
+		return bd.buildGoal(tr, leafs[i])
+	})
+	if err != nil {
+		return err
+	}
+	tr.doneProject(prj, bd.activity("building"), time.Since(start))
+	return nil
+}
+
+func (bd *Builder) start() {
+	switch {
+	case bd.plan != nil:
+		bd.jobs, bd.report = nil, nil
+	case bd.parent != nil:
+		bd.jobs, bd.report = bd.parent.jobs.share(), bd.parent.report
+	default:
+		bd.jobs, bd.report = newJobs(bd.MaxJobs), newBuildReport()
+	}
+	bd.hashes, bd.keepOn = bd.Fingerprints, bd.KeepGoing
+	bd.failed, bd.fails = nil, nil
+	bd.interrupted = nil
+}
+
+// reportDone completes the report unless it belongs to the parent build.
+func (bd *Builder) reportDone(err error) {
+	if bd.parent == nil {
+		bd.report.done(err)
+	}
+}
+
+// Report returns the report of bd's last build. It is nil before the first
+// build and after planning.
+func (bd *Builder) Report() *BuildReport { return bd.report }
+
+// fail records that g failed with err or has to be skipped if err is nil. If
+// the build shall not keep going, it stops the jobs before g's build lock is
+// released, so no other goal that depends on g starts its actions. It then
+// returns err, or errJobsStopped for a skipped goal.
+func (bd *Builder) fail(g *Goal, err error) error {
+	bd.failMu.Lock()
+	defer bd.failMu.Unlock()
+	if bd.failed == nil {
+		bd.failed = make(map[*Goal]bool)
+	}
+	bd.failed[g] = true
+	if !bd.KeepGoing {
+		bd.jobs.stop()
+		if err == nil {
+			err = errJobsStopped
+		}
+		return err
+	}
+	if err != nil {
+		bd.fails = append(bd.fails, err)
+	}
+	return nil
+}
+
+func (bd *Builder) failedPremise(pres []*Goal) *Goal {
+	bd.failMu.Lock()
+	defer bd.failMu.Unlock()
+	for _, pre := range pres {
+		if bd.failed[pre] {
+			return pre
 		}
 	}
-	tr.doneProject(prj, "building", time.Since(start))
 	return nil
 }
 
+func (bd *Builder) restoreState(prj *Project) {
+	if prj.State != nil {
+		prj.State.restore(prj, bd.hashes)
+	}
+}
+
+func (bd *Builder) saveState(prj *Project) error {
+	if prj.State == nil || bd.plan != nil {
+		return nil
+	}
+	if err := prj.State.record(prj, bd.bid); err != nil {
+		return err
+	}
+	return prj.State.Save()
+}
+
 func (bd *Builder) buildGoal(tr *Trace, g *Goal) error {
 	if g.LockBuild() == 0 {
 		return nil
 	}
 	defer g.Unlock()
 
+	start := time.Now()
 	tr = tr.pushGoal(g)
 	tr.checkGoal(g)
 	if len(g.ResultOf()) == 0 {
+		bd.goalDone(tr, g, StatusUpToDate, "", start, nil)
 		return nil
 	}
+	var pres []*Goal
 	for _, act := range g.ResultOf() {
 		for _, pre := range act.Premises() {
-			if err := bd.buildGoal(tr, pre); err != nil {
-				return err
+			if slices.Index(pres, pre) < 0 {
+				pres = append(pres, pre)
 			}
 		}
 	}
+	err := bd.jobs.each(len(pres), func(i int) error {
+		return bd.buildGoal(tr, pres[i])
+	})
+	if err != nil {
+		bd.report.notRun(g.ResultOf(), StatusSkipped, "build stopped")
+		bd.goalDone(tr, g, StatusSkipped, "build stopped", start, nil)
+		return err
+	}
+	if pre := bd.failedPremise(pres); pre != nil {
+		tr.Warn("skipping `goal` because of failed `premise`",
+			`goal`, g,
+			`premise`, pre,
+		)
+		reason := "failed premise " + pre.Name()
+		bd.report.notRun(g.ResultOf(), StatusSkipped, reason)
+		bd.goalDone(tr, g, StatusSkipped, reason, start, nil)
+		return bd.fail(g, nil)
+	}
+
+	chg, err := bd.updateGoal(tr, g)
+	switch {
+	case errors.Is(err, errJobsStopped):
+		bd.goalDone(tr, g, StatusSkipped, "build stopped", start, nil)
+		return bd.fail(g, err)
+	case err != nil:
+		var aerr *ActionError
+		if !errors.As(err, &aerr) {
+			err = fmt.Errorf("goal %s: %w", tr.GoalPath(), err)
+		}
+		bd.goalDone(tr, g, StatusFailed, "", start, err)
+		return bd.fail(g, err)
+	case chg:
+		bd.goalDone(tr, g, StatusRebuilt, "", start, nil)
+	default:
+		bd.goalDone(tr, g, StatusUpToDate, "", start, nil)
+	}
+	return nil
+}
 
-	_, err := bd.updateGoal(tr, g)
-	return err
+func (bd *Builder) goalDone(tr *Trace, g *Goal, st BuildStatus, reason string, start time.Time, err error) {
+	bd.report.goal(g, st, reason, start, err)
+	tr.goalDone(g, time.Since(start), err)
 }
 
-func (bd *Builder) Describe(*Action, *Env) string {
-	return "Build project" // TODO better description
+func (bd *Builder) Describe(a *Action, _ *Env) string {
+	if a == nil {
+		return "Build project"
+	}
+	var sb strings.Builder
+	sb.WriteString("Build")
+	for _, res := range a.Results() {
+		if res.IsAbstract() {
+			continue
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(res.Name())
+	}
+	return sb.String()
 }
 
+// Do builds the sub-projects and the [SubGoal] goals that are results of a.
+// Goals of sub-projects that are results of a are built with their project.
+// The sub-projects are built by a new Builder using tr and env from the parent
+// project's build. When run by a Builder, the sub-build uses the job slots,
+// the Fingerprints and KeepGoing settings and the [BuildReport] of the parent
+// build, while a's job slot is released. Otherwise it uses the configuration
+// of bd.
 func (bd *Builder) Do(tr *Trace, a *Action, env *Env) error {
-	var prjs []*Project
+	var (
+		prjs []*Project
+		gs   []*Goal
+	)
 	for _, res := range a.Results() {
-		switch a := res.Artefact.(type) {
+		switch atf := res.Artefact.(type) {
 		case Abstract:
 			continue
 		case *Project:
-			prjs = append(prjs, a)
+			prjs = append(prjs, atf)
+		case SubGoal:
+			gs = append(gs, atf.Goal)
 		default:
-			return fmt.Errorf("illegal project build target %T", res)
+			return fmt.Errorf("illegal project build target %T", res.Artefact)
 		}
 	}
-	for _, prj := range prjs {
-		if err := bd.buildPrj(tr, prj); err != nil {
-			return err
+	gs = slices.DeleteFunc(gs, func(g *Goal) bool {
+		return slices.Contains(prjs, g.Project())
+	})
+	parent := tr.updater
+	build := func() error {
+		for _, prj := range prjs {
+			if err := bd.sub(tr, env, parent).project(tr, prj); err != nil {
+				return err
+			}
 		}
+		return bd.sub(tr, env, parent).goals(tr, gs)
+	}
+	if parent == nil {
+		return build()
+	}
+	return parent.jobs.yield(build)
+}
+
+// sub returns a new Builder for sub-projects that is part of the build of
+// parent. If parent is nil, the sub-build has the configuration of bd.
+func (bd *Builder) sub(tr *Trace, env *Env, parent *updater) *Builder {
+	if parent == nil {
+		return &Builder{
+			updater:      updater{trace: tr, env: env},
+			MaxJobs:      bd.MaxJobs,
+			Fingerprints: bd.Fingerprints,
+			KeepGoing:    bd.KeepGoing,
+		}
+	}
+	return &Builder{
+		updater:      updater{trace: tr, env: env},
+		Fingerprints: parent.hashes,
+		KeepGoing:    parent.keepOn,
+		parent:       parent,
 	}
-	return nil
 }
 
+// WriteHash does not hash anything. Sub-projects check their goals themselves
+// when they are built.
 func (bd *Builder) WriteHash(h hash.Hash, a *Action, env *Env) (bool, error) {
-	return false, nil // TODO good idea for how to hash build project operation
+	return false, nil
 }
 
 type BuildTracer interface {
@@ -153,13 +445,20 @@ type BuildTracer interface {
 
 	RunAction(*Trace, *Action)
 	RunImplicitAction(*Trace, *Action)
+	// ActionDone is called after each RunAction and RunImplicitAction. The
+	// error is the one returned from the operation, even if it is ignored.
+	ActionDone(t *Trace, a *Action, dt time.Duration, err error)
 
 	ScheduleResTimeZero(t *Trace, a *Action, res *Goal)
 	ScheduleNotPremises(t *Trace, a *Action, res *Goal)
 	SchedulePreTimeZero(t *Trace, a *Action, res, pre *Goal)
 	ScheduleOutdated(t *Trace, a *Action, res, pre *Goal)
+	ScheduleHashChanged(t *Trace, a *Action, res *Goal)
 
 	CheckGoal(t *Trace, g *Goal)
 	GoalUpToDate(t *Trace, g *Goal)
 	GoalNeedsActions(t *Trace, g *Goal, n int)
+	// GoalDone is called when the update of a goal is finished, with the
+	// error that made the goal fail, if any.
+	GoalDone(t *Trace, g *Goal, dt time.Duration, err error)
 }
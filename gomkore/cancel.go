@@ -0,0 +1,74 @@
+package gomkore
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InterruptedError is returned from builds and updates when the context of
+// their trace was cancelled, e.g. by a signal.
+type InterruptedError struct {
+	// Cause is the cause of the context cancellation.
+	Cause error
+
+	// Actions are the actions that were interrupted while they were running.
+	Actions []*Action
+}
+
+func (e *InterruptedError) Error() string {
+	if len(e.Actions) == 0 {
+		return fmt.Sprintf("interrupted: %s", e.Cause)
+	}
+	as := make([]string, len(e.Actions))
+	for i, a := range e.Actions {
+		as[i] = a.String()
+	}
+	return fmt.Sprintf("interrupted: %s; running actions: %s",
+		e.Cause,
+		strings.Join(as, ", "),
+	)
+}
+
+func (e *InterruptedError) Unwrap() error { return e.Cause }
+
+// resultStates returns the state times of the removable results of a before
+// it runs, see [Action.removePartial]. Results whose state cannot be
+// determined count as not existing.
+func (a *Action) resultStates() map[*Goal]time.Time {
+	var sts map[*Goal]time.Time
+	for _, res := range a.Results() {
+		ra, ok := res.Artefact.(RemovableArtefact)
+		if !ok || !res.Removable {
+			continue
+		}
+		if sts == nil {
+			sts = make(map[*Goal]time.Time)
+		}
+		t, _ := ra.StateAt(a.Project())
+		sts[res] = t
+	}
+	return sts
+}
+
+// removePartial removes the removable results of a whose state changed from
+// the state before an interrupted run of a, see [Action.resultStates].
+// Comparing states instead of the start time works with any [Clock] and
+// [FileSystem]. With coarse timestamps a result may be kept although it was
+// changed.
+func (a *Action) removePartial(tr *Trace, before map[*Goal]time.Time) {
+	for _, res := range a.Results() {
+		pre, ok := before[res]
+		if !ok {
+			continue
+		}
+		ra := res.Artefact.(RemovableArtefact)
+		if t, err := ra.StateAt(a.Project()); err != nil || t.IsZero() || t.Equal(pre) {
+			continue
+		}
+		tr.pushGoal(res).removeArtefact(res)
+		if err := ra.Remove(a.Project()); err != nil {
+			tr.Warn(err.Error())
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package gomkore
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type Changer struct {
 	updater
@@ -18,29 +21,41 @@ func NewChanger(tr *Trace, env *Env) (*Changer, error) {
 	}, nil
 }
 
-func (chg *Changer) Goals(gs ...*Goal) error {
+// Plan returns the actions that [Changer.Goals] would run to propagate changes
+// of gs, without running them.
+func (chg *Changer) Plan(gs ...*Goal) (*Plan, error) {
+	pl := newPlan()
+	chg.plan = pl
+	defer func() { chg.plan = nil }()
+	err := chg.Goals(gs...)
+	return pl, err
+}
+
+func (chg *Changer) Goals(gs ...*Goal) (err error) {
 	if len(gs) == 0 {
 		return nil
 	}
+	chg.interrupted = nil
 	var prj *Project
 	defer func() {
+		err = chg.interruption(chg.trace, err)
 		if prj != nil {
-			chg.trace.doneProject(prj, "updating", 0) // TODO duration
+			chg.trace.doneProject(prj, chg.activity("updating"), 0) // TODO duration
 			prj.Unlock()
 		}
 	}()
 	for _, g := range gs {
 		if p := g.Project(); p != prj {
 			if prj != nil {
-				chg.trace.doneProject(prj, "updating", 0) // TODO duration
+				chg.trace.doneProject(prj, chg.activity("updating"), 0) // TODO duration
 				prj.Unlock()
 			}
 			prj = p
-			chg.trace.startProject(prj, "updating")
+			chg.trace.startProject(prj, chg.activity("updating"))
 			if chg.env == nil {
 				chg.env = DefaultEnv(chg.trace)
 			}
-			prj.LockBuild()
+			chg.bid = prj.LockBuild()
 		}
 		chg.trace.checkGoal(g)
 		for _, act := range g.PremiseOf() {
@@ -57,7 +72,10 @@ func (chg *Changer) Goals(gs ...*Goal) error {
 
 func (chg *Changer) update(t *Trace, g *Goal) error {
 	t = t.pushGoal(g)
-	if ok, err := chg.updateGoal(t, g); err != nil {
+	start := time.Now()
+	ok, err := chg.updateGoal(t, g)
+	t.goalDone(g, time.Since(start), err)
+	if err != nil {
 		return err
 	} else if ok {
 		for _, act := range g.PremiseOf() {
@@ -5,13 +5,19 @@ import (
 )
 
 func Clean(prj *Project, dryrun bool, tr *Trace) error {
+	return CleanGoals(prj, prj.Goals(nil), dryrun, tr)
+}
+
+// CleanGoals removes the removable artefacts of the goals gs from project prj.
+// Use [Project.SelectGoals] to clean goals selected by labels.
+func CleanGoals(prj *Project, gs []*Goal, dryrun bool, tr *Trace) error {
 	prj.LockBuild()
 	defer prj.Unlock()
 	start := time.Now()
 	tr = tr.pushProject(prj)
 	tr.startProject(prj, "cleaning")
 	// TODO recursive follow project structure to allow empty dirs to be removed
-	for _, g := range prj.Goals(nil) {
+	for _, g := range gs {
 		if len(g.ResultOf()) == 0 {
 			continue
 		}
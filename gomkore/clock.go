@@ -0,0 +1,66 @@
+package gomkore
+
+import "time"
+
+// Clock provides the current time of a [Project], see [Project.Now]. Tests of
+// build graphs can set a project's Clock to control time without sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the [Clock] interface.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time { return f() }
+
+// SystemClock is the [Clock] that uses [time.Now].
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// Now returns the current time according to the clock of prj. If prj has no
+// Clock set, the clock of its parent is used. The default is [SystemClock].
+func (prj *Project) Now() time.Time {
+	for p := prj; p != nil; p = p.parent {
+		if p.Clock != nil {
+			return p.Clock.Now()
+		}
+	}
+	return time.Now()
+}
+
+// TimePolicy decides how [Goal.CheckPreTimes] compares the state time of a
+// result with the state times of its premises. The zero value considers a
+// result outdated only if a premise is strictly newer.
+type TimePolicy struct {
+	// Tolerance is the maximum difference of two state times that are still
+	// considered to be equal. Use it with file systems whose timestamps are
+	// coarse or whose clocks are not in sync with the build host.
+	Tolerance time.Duration
+
+	// EqualOutdated makes a result outdated if its state time equals that of
+	// a premise. Otherwise a result is considered up-to-date in this case,
+	// which is needed when copies preserve the modification times.
+	EqualOutdated bool
+}
+
+// Outdated reports whether a result with state time res is outdated with
+// respect to a premise with state time pre.
+func (p TimePolicy) Outdated(res, pre time.Time) bool {
+	d := pre.Sub(res)
+	if d.Abs() <= p.Tolerance {
+		return p.EqualOutdated
+	}
+	return d > 0
+}
+
+// TimePolicy returns the time policy of prj. If prj has no Times set, the
+// policy of its parent is used. The default is the zero [TimePolicy].
+func (prj *Project) TimePolicy() TimePolicy {
+	for p := prj; p != nil; p = p.parent {
+		if p.Times != nil {
+			return *p.Times
+		}
+	}
+	return TimePolicy{}
+}
@@ -0,0 +1,59 @@
+package gomkore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimePolicy_Outdated(t *testing.T) {
+	t0 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		policy   TimePolicy
+		pre      time.Duration
+		outdated bool
+	}{
+		{TimePolicy{}, -time.Second, false},
+		{TimePolicy{}, 0, false},
+		{TimePolicy{}, time.Nanosecond, true},
+		{TimePolicy{EqualOutdated: true}, 0, true},
+		{TimePolicy{EqualOutdated: true}, -time.Nanosecond, false},
+		{TimePolicy{Tolerance: time.Second}, time.Second, false},
+		{TimePolicy{Tolerance: time.Second}, time.Second + 1, true},
+		{TimePolicy{Tolerance: time.Second, EqualOutdated: true}, -time.Second, true},
+		{TimePolicy{Tolerance: time.Second, EqualOutdated: true}, -2 * time.Second, false},
+	}
+	for _, test := range tests {
+		if o := test.policy.Outdated(t0, t0.Add(test.pre)); o != test.outdated {
+			t.Errorf("%+v with premise %s: outdated=%t, want %t",
+				test.policy,
+				test.pre,
+				o,
+				test.outdated,
+			)
+		}
+	}
+}
+
+func TestProject_inheritTime(t *testing.T) {
+	t0 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	prj := NewProject("prj")
+	sub := NewProject("sub")
+	if _, err := prj.Goal(sub); err != nil {
+		t.Fatal(err)
+	}
+	if p := sub.TimePolicy(); p != (TimePolicy{}) {
+		t.Errorf("unexpected default policy %+v", p)
+	}
+	prj.Clock = ClockFunc(func() time.Time { return t0 })
+	prj.Times = &TimePolicy{EqualOutdated: true}
+	if now := sub.Now(); !now.Equal(t0) {
+		t.Errorf("sub-project does not use parent clock: %s", now)
+	}
+	if p := sub.TimePolicy(); !p.EqualOutdated {
+		t.Errorf("sub-project does not use parent policy: %+v", p)
+	}
+	sub.Times = &TimePolicy{Tolerance: time.Second}
+	if p := sub.TimePolicy(); p.EqualOutdated || p.Tolerance != time.Second {
+		t.Errorf("sub-project does not use own policy: %+v", p)
+	}
+}
@@ -0,0 +1,146 @@
+package gomkore
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// UnknownGoalError is returned when a name or pattern does not match any goal
+// of a project. It suggests the names of goals that are close to Name.
+type UnknownGoalError struct {
+	Project     *Project
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownGoalError) Error() string {
+	msg := fmt.Sprintf("no goal '%s' in project '%s'", e.Name, e.Project.String())
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf("; did you mean '%s'?", strings.Join(e.Suggestions, "', '"))
+	}
+	return msg
+}
+
+// FindGoal returns the goal with the given name or nil, if there is no such
+// goal. Names use '/' as path separator. If several goals have the same name,
+// the goal that was created first is returned.
+func (prj *Project) FindGoal(name string) *Goal {
+	if gs := prj.names[filepath.ToSlash(name)]; len(gs) > 0 {
+		return gs[0]
+	}
+	return nil
+}
+
+// FindGoals returns all goals that match any of the patterns, sorted by name.
+// Patterns without the meta characters '*', '?' and '[' match goal names
+// exactly. Otherwise, the pattern elements separated by '/' are matched
+// against the elements of goal names as with [path.Match]. The pattern element
+// "**" matches any number of name elements, e.g. "doc/**" matches all goals
+// in doc. If a pattern does not match any goal, an [*UnknownGoalError] is
+// returned.
+func (prj *Project) FindGoals(patterns ...string) (gs []*Goal, err error) {
+	for _, pat := range patterns {
+		pat = filepath.ToSlash(pat)
+		if !strings.ContainsAny(pat, "*?[") {
+			ngs := prj.names[pat]
+			if len(ngs) == 0 {
+				return nil, prj.unknownGoal(pat)
+			}
+			gs = append(gs, ngs...)
+			continue
+		}
+		if _, err := path.Match(pat, ""); err != nil {
+			return nil, fmt.Errorf("goal pattern '%s': %w", pat, err)
+		}
+		found := false
+		for n, ngs := range prj.names {
+			if matchGoalName(strings.Split(pat, "/"), strings.Split(n, "/")) {
+				gs = append(gs, ngs...)
+				found = true
+			}
+		}
+		if !found {
+			return nil, prj.unknownGoal(pat)
+		}
+	}
+	slices.SortFunc(gs, func(g, h *Goal) int {
+		return strings.Compare(g.Name(), h.Name())
+	})
+	return slices.Compact(gs), nil
+}
+
+func matchGoalName(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := range len(name) + 1 {
+				if matchGoalName(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+const maxGoalSuggestions = 3
+
+func (prj *Project) unknownGoal(name string) error {
+	type candidate struct {
+		name string
+		dist int
+	}
+	var cs []candidate
+	maxDist := len(name)/3 + 1
+	for n := range prj.names {
+		d := editDistance(name, n)
+		if path.Base(n) == path.Base(name) {
+			d = 0
+		}
+		if d <= maxDist {
+			cs = append(cs, candidate{n, d})
+		}
+	}
+	slices.SortFunc(cs, func(a, b candidate) int {
+		if a.dist != b.dist {
+			return a.dist - b.dist
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	err := &UnknownGoalError{Project: prj, Name: name}
+	for i := 0; i < len(cs) && i < maxGoalSuggestions; i++ {
+		err.Suggestions = append(err.Suggestions, cs[i].name)
+	}
+	return err
+}
+
+// editDistance computes the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := range ra {
+		diag := row[0]
+		row[0] = i + 1
+		for j := range rb {
+			d := diag
+			if ra[i] != rb[j] {
+				d = 1 + min(diag, row[j], row[j+1])
+			}
+			diag, row[j+1] = row[j+1], d
+		}
+	}
+	return row[len(rb)]
+}
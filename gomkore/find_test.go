@@ -0,0 +1,67 @@
+package gomkore
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestProject_FindGoals(t *testing.T) {
+	prj := NewProject(t.Name())
+	for _, n := range []string{"test", "dist/foo", "dist/bar", "doc/index.html", "doc/img/a.png"} {
+		if _, err := prj.Goal(Abstract(n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g := prj.FindGoal("dist/foo"); g == nil || g.Name() != "dist/foo" {
+		t.Errorf("FindGoal: unexpected goal %v", g)
+	}
+	names := func(pats ...string) []string {
+		gs, err := prj.FindGoals(pats...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ns []string
+		for _, g := range gs {
+			ns = append(ns, g.Name())
+		}
+		return ns
+	}
+	if ns := names("dist/*"); !slices.Equal(ns, []string{"dist/bar", "dist/foo"}) {
+		t.Errorf("dist/*: %v", ns)
+	}
+	if ns := names("doc/**"); !slices.Equal(ns, []string{"doc/img/a.png", "doc/index.html"}) {
+		t.Errorf("doc/**: %v", ns)
+	}
+	if ns := names("**/*.png", "test", "doc/img/a.png"); !slices.Equal(ns, []string{"doc/img/a.png", "test"}) {
+		t.Errorf("**/*.png test: %v", ns)
+	}
+
+	_, err := prj.FindGoals("tset")
+	var uerr *UnknownGoalError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(uerr.Suggestions, []string{"test"}) {
+		t.Errorf("unexpected suggestions: %v", uerr.Suggestions)
+	}
+	if _, err = prj.FindGoals("foo"); !errors.As(err, &uerr) || !slices.Equal(uerr.Suggestions, []string{"dist/foo"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_editDistance(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		d    int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"test", "tset", 2},
+	} {
+		if d := editDistance(c.a, c.b); d != c.d {
+			t.Errorf("distance '%s' '%s' = %d, want %d", c.a, c.b, d, c.d)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package gomkore
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// FileSystem is used by file artefacts and operations, e.g. those of package
+// mkfs, to access the files of a [Project]. All names are paths of the OS as
+// returned by [Project.AbsPath]. This allows to test build graphs without
+// touching the disk.
+type FileSystem interface {
+	Stat(name string) (fs.FileInfo, error)
+	// ReadDir returns the entries of directory name sorted by name.
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Open(name string) (io.ReadCloser, error)
+	// Create creates or truncates the file name with permissions perm.
+	Create(name string, perm fs.FileMode) (io.WriteCloser, error)
+	// CreateNew creates the file name with permissions perm. It fails with
+	// an error that matches [fs.ErrExist] if name already exists.
+	CreateNew(name string, perm fs.FileMode) (io.WriteCloser, error)
+	// Rename moves oldname to newname, replacing an existing file newname.
+	Rename(oldname, newname string) error
+	MkdirAll(name string, perm fs.FileMode) error
+	Remove(name string) error
+}
+
+// OSFileSystem implements [FileSystem] with the functions of package os.
+type OSFileSystem struct{}
+
+var _ FileSystem = OSFileSystem{}
+
+func (OSFileSystem) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (OSFileSystem) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (OSFileSystem) Open(name string) (io.ReadCloser, error)    { return os.Open(name) }
+
+func (OSFileSystem) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+}
+
+func (OSFileSystem) CreateNew(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+}
+
+func (OSFileSystem) Rename(oldname, newname string) error { return os.Rename(oldname, newname) }
+
+func (OSFileSystem) MkdirAll(name string, perm fs.FileMode) error { return os.MkdirAll(name, perm) }
+func (OSFileSystem) Remove(name string) error                     { return os.Remove(name) }
+
+// FileSystem returns the file system of prj. If prj has no FS set, the file
+// system of its parent is used. The default is [OSFileSystem].
+func (prj *Project) FileSystem() FileSystem {
+	for p := prj; p != nil; p = p.parent {
+		if p.FS != nil {
+			return p.FS
+		}
+	}
+	return OSFileSystem{}
+}
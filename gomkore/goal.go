@@ -1,9 +1,12 @@
 package gomkore
 
 import (
+	"bytes"
 	"fmt"
+	"hash"
 	"math"
 	"reflect"
+	"runtime"
 	"slices"
 	"sync"
 	"time"
@@ -24,6 +27,16 @@ type Artefact interface {
 	StateAt(in *Project) (time.Time, error)
 }
 
+// HashableArtefact is implemented by artefacts that can write a digest of
+// their current content. Content digests are used by [Action.Fingerprint].
+type HashableArtefact interface {
+	Artefact
+
+	// WriteHash writes the digest of the artefact's content to h. It returns
+	// false if the artefact does not exist or cannot be hashed.
+	WriteHash(h hash.Hash, in *Project) (bool, error)
+}
+
 type RemovableArtefact interface {
 	Artefact
 	Exists(in *Project) (bool, error)
@@ -60,6 +73,29 @@ func (a Abstract) StateAt(prj *Project) (t time.Time, err error) {
 	return t, nil
 }
 
+// WriteHash writes the digests of the premises of all implicit actions that
+// result in a. If a is the result of an action with an operation, it cannot be
+// hashed.
+func (a Abstract) WriteHash(h hash.Hash, prj *Project) (bool, error) {
+	g, err := prj.Goal(a)
+	if err != nil {
+		return false, err
+	}
+	for _, act := range g.ResultOf() {
+		if act.Op != nil {
+			return false, nil
+		}
+	}
+	for _, act := range g.ResultOf() {
+		for _, pre := range act.Premises() {
+			if ok, err := pre.WriteHash(h); !ok || err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
 type UpdateMode uint
 
 const (
@@ -107,6 +143,7 @@ type Goal struct {
 	UpdateMode UpdateMode
 	Artefact   Artefact
 	Removable  bool
+	Labels     Labels
 
 	prj       *Project
 	resultOf  []*Action
@@ -132,6 +169,17 @@ func (g *Goal) PremiseOf() []*Action { return g.premiseOf }
 // PostAction returns [Goal.PremiseOf]()[i]
 func (g *Goal) PostAction(i int) *Action { return g.premiseOf[i] }
 
+// WriteHash writes the name and the content digest of g's artefact to h. It
+// returns false if the artefact is not a [HashableArtefact] or cannot be hashed.
+func (g *Goal) WriteHash(h hash.Hash) (bool, error) {
+	hatf, ok := g.Artefact.(HashableArtefact)
+	if !ok {
+		return false, nil
+	}
+	fmt.Fprintln(h, g.Name())
+	return hatf.WriteHash(h, g.Project())
+}
+
 func (g *Goal) IsAbstract() bool {
 	_, ok := g.Artefact.(Abstract)
 	return ok
@@ -203,42 +251,87 @@ func (g *Goal) String() string {
 }
 
 // CheckPreTimes check if g needs to be updated according to the timestamps of
-// all of its premises.
+// all of its premises. The timestamps are compared with the
+// [Project.TimePolicy] of g's project.
 func (g *Goal) CheckPreTimes(tr *Trace) (chgs []int, err error) {
 	// TODO Consistency for concurrent builds
+	chgs, _, err = g.checkPre(tr, nil, false, nil)
+	return chgs, err
+}
+
+// CheckPreHashes checks if g needs to be updated according to the
+// fingerprints of its actions, see [Action.Fingerprint]. Actions that cannot
+// be fingerprinted or that have no fingerprint from a previous run are checked
+// with the timestamps of their premises. Tangible results that do not exist
+// always require their actions to be run.
+func (g *Goal) CheckPreHashes(tr *Trace, env *Env) (chgs []int, err error) {
+	chgs, _, err = g.checkPre(tr, env, true, nil)
+	return chgs, err
+}
+
+// checkPre does not change any action if it is used for a plan pl.
+func (g *Goal) checkPre(tr *Trace, env *Env, hashes bool, pl *Plan) (chgs []int, scheds []Schedule, err error) {
 	gaTS, err := g.Artefact.StateAt(g.Project())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	for actIdx, act := range g.ResultOf() {
-		if gaTS.IsZero() {
-			tr.scheduleResTimeZero(act, g)
-			chgs = append(chgs, actIdx)
-			continue
-		} else if len(act.Premises()) == 0 {
-			tr.scheduleNotPremises(act, g)
-			chgs = append(chgs, actIdx)
-			continue
+		var fp []byte
+		if hashes {
+			if fp, err = act.Fingerprint(env); err != nil {
+				return nil, nil, err
+			}
 		}
-	PREMISE_LOOP:
-		for _, pre := range act.Premises() {
-			preTS, err := pre.Artefact.StateAt(g.Project())
-			if err != nil {
-				return nil, err
+		s := Schedule{Action: act, Goal: g}
+		switch {
+		case fp == nil || act.hash == nil:
+			if s.Reason, s.Premise, err = g.checkActTimes(gaTS, act, pl); err != nil {
+				return nil, nil, err
 			}
-			switch {
-			case preTS.IsZero():
-				tr.schedulePreTimeZero(act, g, pre)
-				chgs = append(chgs, actIdx)
-				break PREMISE_LOOP
-			case gaTS.Before(preTS):
-				tr.scheduleOutdated(act, g, pre)
-				chgs = append(chgs, actIdx)
-				break PREMISE_LOOP
+		case gaTS.IsZero() && !g.IsAbstract():
+			s.Reason = SchedResTimeZero
+		case pl.plannedPremise(act) != nil:
+			s.Reason, s.Premise = SchedPrePlanned, pl.plannedPremise(act)
+		case !bytes.Equal(fp, act.hash):
+			s.Reason = SchedHashChanged
+		}
+		if s.Reason != 0 {
+			tr.schedule(s)
+			chgs = append(chgs, actIdx)
+			scheds = append(scheds, s)
+			if pl == nil {
+				act.nextHash = fp
 			}
+		} else if fp != nil && pl == nil {
+			act.hash = fp
 		}
 	}
-	return chgs, nil
+	return chgs, scheds, nil
+}
+
+func (g *Goal) checkActTimes(gaTS time.Time, act *Action, pl *Plan) (ScheduleReason, *Goal, error) {
+	if gaTS.IsZero() {
+		return SchedResTimeZero, nil, nil
+	} else if len(act.Premises()) == 0 {
+		return SchedNoPremises, nil, nil
+	}
+	policy := g.Project().TimePolicy()
+	for _, pre := range act.Premises() {
+		if pl.planned(pre) {
+			return SchedPrePlanned, pre, nil
+		}
+		preTS, err := pre.Artefact.StateAt(g.Project())
+		if err != nil {
+			return 0, nil, err
+		}
+		switch {
+		case preTS.IsZero():
+			return SchedPreTimeZero, pre, nil
+		case policy.Outdated(gaTS, preTS):
+			return SchedOutdated, pre, nil
+		}
+	}
+	return 0, nil, nil
 }
 
 // LockBuild locks g once for the current build of g's project. If g was already
@@ -268,8 +361,11 @@ func (g *Goal) LockPreActions(gid uintptr) {
 				panic("no next to lock but todo > 0")
 			}
 		}
-		blockGID := g.resultOf[i].tryLock(gid)
-		if blockGID > gid { // I lost => restart
+		switch blockGID := g.resultOf[i].tryLock(gid); {
+		case blockGID == 0:
+			locked.Set(i)
+			todo--
+		case blockGID > gid: // I lost => restart
 			for j, ok := locked.NextSet(0); ok; j, ok = locked.NextSet(j + 1) {
 				g.resultOf[j].unlock()
 			}
@@ -277,9 +373,8 @@ func (g *Goal) LockPreActions(gid uintptr) {
 			todo = len(g.ResultOf())
 			// Sleep for short to not stay in the winner's way
 			time.Sleep(time.Millisecond) // TODO reasonable?
-		} else {
-			locked.Set(i)
-			todo--
+		default: // I win => the loser will give way, retry later
+			runtime.Gosched()
 		}
 	}
 }
@@ -0,0 +1,116 @@
+package gomkore
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var errJobsStopped = errors.New("jobs stopped")
+
+// jobs limits the number of operations that run concurrently. A nil *jobs runs
+// everything sequentially in the calling goroutine.
+type jobs struct {
+	sem     chan struct{}
+	stopped atomic.Bool
+}
+
+func newJobs(max int) *jobs {
+	if max < 2 {
+		return nil
+	}
+	return &jobs{sem: make(chan struct{}, max)}
+}
+
+// acquire blocks until a job slot is available. It returns false if jobs were
+// stopped before or while waiting for the slot.
+func (j *jobs) acquire() bool {
+	if j == nil {
+		return true
+	}
+	if j.stopped.Load() {
+		return false
+	}
+	j.sem <- struct{}{}
+	if j.stopped.Load() {
+		<-j.sem
+		return false
+	}
+	return true
+}
+
+func (j *jobs) release() {
+	if j != nil {
+		<-j.sem
+	}
+}
+
+// share returns jobs that use the same job slots as j but can be stopped
+// independently.
+func (j *jobs) share() *jobs {
+	if j == nil {
+		return nil
+	}
+	return &jobs{sem: j.sem}
+}
+
+// yield calls do without holding the slot of the calling job. The slot is
+// acquired again after do returned, even if j was stopped meanwhile.
+func (j *jobs) yield(do func() error) error {
+	if j == nil {
+		return do()
+	}
+	<-j.sem
+	defer func() { j.sem <- struct{}{} }()
+	return do()
+}
+
+func (j *jobs) stop() {
+	if j != nil {
+		j.stopped.Store(true)
+	}
+}
+
+// each calls do for i in [0;n). Without concurrency the first error is
+// returned immediately. Otherwise all calls run concurrently, the first error
+// stops the jobs and all errors except errJobsStopped are joined.
+func (j *jobs) each(n int, do func(i int) error) error {
+	if j == nil || n < 2 {
+		for i := range n {
+			if err := do(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	wg.Add(n)
+	for i := range n {
+		go func() {
+			defer wg.Done()
+			if err := do(i); err != nil {
+				j.stop()
+				if !errors.Is(err, errJobsStopped) {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	switch len(errs) {
+	case 0:
+		if j.stopped.Load() {
+			return errJobsStopped
+		}
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
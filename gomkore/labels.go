@@ -0,0 +1,235 @@
+package gomkore
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Labels is a set of arbitrary labels like "docs", "release" or "slow" that
+// can be attached to goals and actions. Goals can be selected by labels with a
+// [LabelExpr]. [Labels.Add] sorts the labels, but labels can also be assigned
+// directly in any order.
+type Labels []string
+
+// Has reports whether ls contains label l.
+func (ls Labels) Has(l string) bool { return slices.Contains(ls, l) }
+
+// Add adds the labels l that are not yet in ls and sorts ls.
+func (ls *Labels) Add(l ...string) {
+	for _, lb := range l {
+		if !ls.Has(lb) {
+			*ls = append(*ls, lb)
+		}
+	}
+	slices.Sort(*ls)
+}
+
+// HasLabel reports whether g or any action that results in g has label l.
+func (g *Goal) HasLabel(l string) bool {
+	if g.Labels.Has(l) {
+		return true
+	}
+	for _, a := range g.ResultOf() {
+		if a.Labels.Has(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// LabelExpr is a boolean expression over labels, see [ParseLabelExpr].
+type LabelExpr interface {
+	// Match evaluates the expression with has telling which labels are set.
+	Match(has func(label string) bool) bool
+	String() string
+}
+
+// MatchGoal reports whether the labels of g match expr, see [Goal.HasLabel].
+func MatchGoal(expr LabelExpr, g *Goal) bool { return expr.Match(g.HasLabel) }
+
+// SelectGoals returns the goals of prj that match expr, sorted by name.
+func (prj *Project) SelectGoals(expr LabelExpr) (gs []*Goal) {
+	for _, g := range prj.goals {
+		if MatchGoal(expr, g) {
+			gs = append(gs, g)
+		}
+	}
+	slices.SortFunc(gs, func(g, h *Goal) int {
+		return strings.Compare(g.Name(), h.Name())
+	})
+	return gs
+}
+
+// ParseLabelExpr parses a label expression. Labels are combined with the
+// operators '!' (not), '&' (and) and '|' (or), in the order of precedence.
+// Parentheses can be used for grouping. E.g. "test & !slow" selects all goals
+// labelled test that are not labelled slow. Labels consist of all characters
+// except white space, operators and parentheses.
+func ParseLabelExpr(s string) (LabelExpr, error) {
+	p := labelParser{in: s}
+	e, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("label expression '%s': %w", s, err)
+	}
+	if tok := p.next(); tok != "" {
+		return nil, fmt.Errorf("label expression '%s': unexpected '%s'", s, tok)
+	}
+	return e, nil
+}
+
+type labelParser struct {
+	in  string
+	tok string
+}
+
+func (p *labelParser) next() string {
+	if p.tok != "" {
+		tok := p.tok
+		p.tok = ""
+		return tok
+	}
+	p.in = strings.TrimLeft(p.in, " \t\n\r")
+	if p.in == "" {
+		return ""
+	}
+	n := strings.IndexAny(p.in, "!&|() \t\n\r")
+	switch {
+	case n < 0:
+		n = len(p.in)
+	case n == 0:
+		n = 1
+	}
+	tok := p.in[:n]
+	p.in = p.in[n:]
+	return tok
+}
+
+func (p *labelParser) peek() string {
+	if p.tok == "" {
+		p.tok = p.next()
+	}
+	return p.tok
+}
+
+func (p *labelParser) or() (LabelExpr, error) {
+	var es labelOr
+	for {
+		e, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, e)
+		if p.peek() != "|" {
+			break
+		}
+		p.next()
+	}
+	if len(es) == 1 {
+		return es[0], nil
+	}
+	return es, nil
+}
+
+func (p *labelParser) and() (LabelExpr, error) {
+	var es labelAnd
+	for {
+		e, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, e)
+		if p.peek() != "&" {
+			break
+		}
+		p.next()
+	}
+	if len(es) == 1 {
+		return es[0], nil
+	}
+	return es, nil
+}
+
+func (p *labelParser) not() (LabelExpr, error) {
+	switch tok := p.next(); tok {
+	case "":
+		return nil, fmt.Errorf("unexpected end")
+	case "!":
+		e, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return labelNot{e}, nil
+	case "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return e, nil
+	case "&", "|", ")":
+		return nil, fmt.Errorf("unexpected '%s'", tok)
+	default:
+		return labelIs(tok), nil
+	}
+}
+
+type labelIs string
+
+func (e labelIs) Match(has func(string) bool) bool { return has(string(e)) }
+func (e labelIs) String() string                   { return string(e) }
+
+type labelNot struct{ e LabelExpr }
+
+func (e labelNot) Match(has func(string) bool) bool { return !e.e.Match(has) }
+
+func (e labelNot) String() string {
+	if _, ok := e.e.(labelIs); ok {
+		return "!" + e.e.String()
+	}
+	return "!(" + e.e.String() + ")"
+}
+
+type labelAnd []LabelExpr
+
+func (e labelAnd) Match(has func(string) bool) bool {
+	for _, x := range e {
+		if !x.Match(has) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e labelAnd) String() string {
+	ss := make([]string, len(e))
+	for i, x := range e {
+		if _, ok := x.(labelOr); ok {
+			ss[i] = "(" + x.String() + ")"
+		} else {
+			ss[i] = x.String()
+		}
+	}
+	return strings.Join(ss, " & ")
+}
+
+type labelOr []LabelExpr
+
+func (e labelOr) Match(has func(string) bool) bool {
+	for _, x := range e {
+		if x.Match(has) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e labelOr) String() string {
+	ss := make([]string, len(e))
+	for i, x := range e {
+		ss[i] = x.String()
+	}
+	return strings.Join(ss, " | ")
+}
@@ -0,0 +1,67 @@
+package gomkore
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseLabelExpr(t *testing.T) {
+	for _, c := range []struct {
+		expr, str string
+		labels    Labels
+		match     bool
+	}{
+		{"docs", "docs", Labels{"docs"}, true},
+		{"!slow", "!slow", Labels{"slow", "test"}, false},
+		{"test & !slow", "test & !slow", Labels{"test"}, true},
+		{"test&!slow", "test & !slow", Labels{"slow", "test"}, false},
+		{"docs | release & !slow", "docs | release & !slow", Labels{"release"}, true},
+		{"(docs | release) & !slow", "(docs | release) & !slow", Labels{"docs", "slow"}, false},
+		{"!(a|b)", "!(a | b)", Labels{"c"}, true},
+		{"docs & test", "docs & test", Labels{"test", "docs"}, true},
+	} {
+		e, err := ParseLabelExpr(c.expr)
+		if err != nil {
+			t.Errorf("'%s': %s", c.expr, err)
+			continue
+		}
+		if s := e.String(); s != c.str {
+			t.Errorf("'%s': unexpected string '%s'", c.expr, s)
+		}
+		if m := e.Match(c.labels.Has); m != c.match {
+			t.Errorf("'%s' match %v = %t", c.expr, c.labels, m)
+		}
+	}
+	for _, expr := range []string{"", "a &", "(a | b", "a b", "|a", "a)"} {
+		if _, err := ParseLabelExpr(expr); err == nil {
+			t.Errorf("no error for '%s'", expr)
+		}
+	}
+}
+
+func TestProject_SelectGoals(t *testing.T) {
+	prj := NewProject(t.Name())
+	doc, _ := prj.Goal(Abstract("doc"))
+	doc.Labels.Add("docs")
+	fast, _ := prj.Goal(Abstract("fast"))
+	slow, _ := prj.Goal(Abstract("slow"))
+	a, _ := prj.NewAction(nil, []*Goal{fast}, nopOp{})
+	a.Labels.Add("test")
+	a, _ = prj.NewAction(nil, []*Goal{slow}, nopOp{})
+	a.Labels.Add("test", "slow", "test")
+	if !slices.Equal(a.Labels, Labels{"slow", "test"}) {
+		t.Errorf("unexpected labels %v", a.Labels)
+	}
+	a.Labels = Labels{"test", "slow"}
+	if a.Labels.Add("slow"); len(a.Labels) != 2 {
+		t.Errorf("duplicate in unsorted labels %v", a.Labels)
+	}
+	ls := Labels{"test", "docs"}
+	if ls.Add("fast"); !slices.Equal(ls, Labels{"docs", "fast", "test"}) {
+		t.Errorf("unexpected labels after adding to unsorted %v", ls)
+	}
+	e, _ := ParseLabelExpr("docs | test & !slow")
+	if gs := prj.SelectGoals(e); !slices.Equal(gs, []*Goal{doc, fast}) {
+		t.Errorf("unexpected selection %v", gs)
+	}
+}
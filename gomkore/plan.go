@@ -0,0 +1,170 @@
+package gomkore
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ScheduleReason tells why an action is scheduled to update a goal.
+type ScheduleReason int
+
+const (
+	// The goal's artefact has no state time, e.g. it does not exist.
+	SchedResTimeZero ScheduleReason = iota + 1
+
+	// The action has no premises.
+	SchedNoPremises
+
+	// A premise of the action has no state time.
+	SchedPreTimeZero
+
+	// A premise of the action is newer than the goal's artefact.
+	SchedOutdated
+
+	// The fingerprint of the action changed, see [Action.Fingerprint].
+	SchedHashChanged
+
+	// A premise of the action is planned to be updated. Only used for plans.
+	SchedPrePlanned
+
+	// The action is required by the goal's update mode. Only used for plans.
+	SchedUpdateMode
+)
+
+func (r ScheduleReason) String() string {
+	switch r {
+	case SchedResTimeZero:
+		return "result missing"
+	case SchedNoPremises:
+		return "no premises"
+	case SchedPreTimeZero:
+		return "premise missing"
+	case SchedOutdated:
+		return "premise newer"
+	case SchedHashChanged:
+		return "fingerprint changed"
+	case SchedPrePlanned:
+		return "premise planned"
+	case SchedUpdateMode:
+		return "update mode"
+	}
+	return fmt.Sprintf("ScheduleReason(%d)", int(r))
+}
+
+// Schedule tells that and why an action is scheduled to update a goal.
+type Schedule struct {
+	Action *Action
+	Goal   *Goal
+	Reason ScheduleReason
+
+	// Premise is the premise that caused the schedule, if any.
+	Premise *Goal
+}
+
+func (s Schedule) String() string {
+	if s.Premise != nil {
+		return fmt.Sprintf("(%s) for [%s]: %s [%s]",
+			s.Action,
+			s.Goal,
+			s.Reason,
+			s.Premise,
+		)
+	}
+	return fmt.Sprintf("(%s) for [%s]: %s", s.Action, s.Goal, s.Reason)
+}
+
+// A Plan lists the actions an update would run, in the order they would be
+// run sequentially. Plans are computed without running any [Operation], see
+// [Builder.Plan] and [Changer.Plan]. Goals that depend on planned actions are
+// considered outdated, even though their premises do not change during
+// planning. Implicit actions are not listed.
+type Plan struct {
+	Steps []Schedule
+
+	actions map[*Action]bool
+	goals   map[*Goal]bool
+	scheds  map[*Action]Schedule
+}
+
+func newPlan() *Plan {
+	return &Plan{
+		actions: make(map[*Action]bool),
+		goals:   make(map[*Goal]bool),
+		scheds:  make(map[*Action]Schedule),
+	}
+}
+
+// Planned reports whether the plan would update goal g.
+func (pl *Plan) Planned(g *Goal) bool { return pl.planned(g) }
+
+// Schedule returns the schedule of action a if the plan would run a. Unlike
+// Steps, this includes implicit actions.
+func (pl *Plan) Schedule(a *Action) (s Schedule, ok bool) {
+	if pl == nil {
+		return s, false
+	}
+	s, ok = pl.scheds[a]
+	return s, ok
+}
+
+func (pl *Plan) planned(g *Goal) bool { return pl != nil && pl.goals[g] }
+
+func (pl *Plan) plannedPremise(a *Action) *Goal {
+	if pl == nil {
+		return nil
+	}
+	for _, pre := range a.Premises() {
+		if pl.goals[pre] {
+			return pre
+		}
+	}
+	return nil
+}
+
+// update selects the actions to update g according to its update mode.
+func (pl *Plan) update(g *Goal, scheds []Schedule) error {
+	var sel []Schedule
+	switch g.UpdateMode.Actions() {
+	case UpdAllActions:
+		for _, act := range g.ResultOf() {
+			i := slices.IndexFunc(scheds, func(s Schedule) bool { return s.Action == act })
+			if i >= 0 {
+				sel = append(sel, scheds[i])
+			} else {
+				sel = append(sel, Schedule{Action: act, Goal: g, Reason: SchedUpdateMode})
+			}
+		}
+	case UpdSomeActions:
+		sel = scheds
+	case UpdAnyAction:
+		for _, act := range g.ResultOf() {
+			if pl.actions[act] {
+				pl.goals[g] = true
+				return nil
+			}
+		}
+		sel = scheds[:1]
+	case UpdOneAction:
+		if l := len(scheds); l > 1 {
+			return fmt.Errorf("%d change actions for update mode One in goal %s",
+				l,
+				g.String(),
+			)
+		}
+		sel = scheds
+	default:
+		return fmt.Errorf("illegal update mode actions: %d", g.UpdateMode.Actions())
+	}
+	for _, s := range sel {
+		if pl.actions[s.Action] {
+			continue
+		}
+		pl.actions[s.Action] = true
+		pl.scheds[s.Action] = s
+		if s.Action.Op != nil {
+			pl.Steps = append(pl.Steps, s)
+		}
+	}
+	pl.goals[g] = true
+	return nil
+}
@@ -2,7 +2,9 @@ package gomkore
 
 import (
 	"fmt"
+	"hash"
 	"os"
+	"path"
 	"path/filepath"
 	"slices"
 	"sync"
@@ -14,10 +16,28 @@ type BuildID = uint64
 type Project struct {
 	Dir string
 
+	// State persists the state of the project's actions between builds if
+	// set, see [OpenState].
+	State *State
+
+	// FS is used to access the project's files, see [Project.FileSystem].
+	FS FileSystem
+
+	// Clock is used to get the current time, see [Project.Now]. It sets the
+	// time of successful action runs in the State and decides when a lock of
+	// the State is stale. Whether results are outdated is decided from the
+	// state times of artefacts, e.g. the modification times of files. Use a
+	// FileSystem that takes its times from Clock to control them as well.
+	Clock Clock
+
+	// Times is the policy to compare state times, see [Project.TimePolicy].
+	Times *TimePolicy
+
 	sync.Mutex
 
 	parent    *Project
 	goals     map[any]*Goal // TODO use key that respect Artefact type correctly
+	names     map[string][]*Goal
 	actions   []*Action
 	lastBuild BuildID
 }
@@ -31,6 +51,7 @@ func NewProject(dir string) *Project {
 	prj := &Project{
 		Dir:   dir,
 		goals: make(map[any]*Goal),
+		names: make(map[string][]*Goal),
 	}
 	return prj
 }
@@ -59,6 +80,8 @@ func (prj *Project) Goal(atf Artefact) (*Goal, error) {
 		prj:      prj,
 	}
 	prj.goals[key] = g
+	n := filepath.ToSlash(g.Name())
+	prj.names[n] = append(prj.names[n], g)
 	return g, nil
 }
 
@@ -76,10 +99,6 @@ func (prj *Project) Goals(addTo []*Goal) []*Goal {
 
 func (prj *Project) Actions() []*Action { return prj.actions }
 
-func (prj *Project) FindGoal(name string) *Goal {
-	return prj.goals[name]
-}
-
 type prjKey string
 
 func (prj *Project) Key() any { return prjKey(prj.Dir) }
@@ -103,20 +122,19 @@ func (prj *Project) String() string {
 	return filepath.Base(tmp)
 }
 
+// StateAt returns the latest state time of prj's leafs. The leafs' artefacts
+// are always evaluated relative to prj, not to in.
 func (prj *Project) StateAt(in *Project) (time.Time, error) {
-	if in == nil {
-		in = prj
-	}
 	leafs := prj.Leafs()
 	if len(leafs) == 0 {
 		return time.Time{}, nil
 	}
-	t, err := leafs[0].Artefact.StateAt(in)
+	t, err := leafs[0].Artefact.StateAt(prj)
 	if err != nil {
 		return time.Time{}, err
 	}
 	for _, l := range leafs[1:] {
-		if u, err := l.Artefact.StateAt(in); err != nil {
+		if u, err := l.Artefact.StateAt(prj); err != nil {
 			return u, err
 		} else if u.After(t) {
 			t = u
@@ -125,6 +143,82 @@ func (prj *Project) StateAt(in *Project) (time.Time, error) {
 	return t, nil
 }
 
+// Parent returns the project that prj is a sub-project of, if any.
+func (prj *Project) Parent() *Project { return prj.parent }
+
+// SubProject returns the goal in prj for its sub-project sub. Unless the goal
+// already has actions, it becomes a result of the action that builds sub with
+// a [Builder], see [Project.SubGoal].
+func (prj *Project) SubProject(sub *Project) (*Goal, error) {
+	g, err := prj.Goal(sub)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.ResultOf()) == 0 {
+		if err := prj.buildSub(sub, g); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// SubGoal returns the goal in prj that stands for goal g of a sub-project of
+// prj. Actions in prj can use it as premise to depend on g. Unless the goal
+// already exists, it is created as the result of an action that builds g with
+// a [Builder]. All goals of one sub-project share this action. If it also
+// builds the goal of the sub-project itself, it builds the whole sub-project.
+func (prj *Project) SubGoal(g *Goal) (*Goal, error) {
+	sub := g.Project()
+	for p := sub.parent; p != prj; p = p.parent {
+		if p == nil {
+			return nil, fmt.Errorf("goal %s: project %s is not a sub-project of %s",
+				g.String(),
+				sub.String(),
+				prj.String(),
+			)
+		}
+	}
+	sg, err := prj.Goal(SubGoal{g})
+	if err != nil {
+		return nil, err
+	}
+	if len(sg.ResultOf()) == 0 {
+		if err := prj.buildSub(sub, sg); err != nil {
+			return nil, err
+		}
+	}
+	return sg, nil
+}
+
+// buildSub adds g to the results of the action that builds sub with a
+// [Builder]. The action is created if there is none yet.
+func (prj *Project) buildSub(sub *Project, g *Goal) error {
+	for _, a := range prj.actions {
+		if _, ok := a.Op.(*Builder); !ok {
+			continue
+		}
+		for _, res := range a.results {
+			switch atf := res.Artefact.(type) {
+			case *Project:
+				if atf != sub {
+					continue
+				}
+			case SubGoal:
+				if atf.Goal.Project() != sub {
+					continue
+				}
+			default:
+				continue
+			}
+			a.results = append(a.results, g)
+			g.resultOf = append(g.resultOf, a)
+			return updateConsistency(a.results)
+		}
+	}
+	_, err := prj.NewAction(nil, []*Goal{g}, new(Builder))
+	return err
+}
+
 func (prj *Project) AbsPath(rel string) (string, error) {
 	if filepath.IsAbs(prj.Dir) {
 		return filepath.Join(prj.Dir, rel), nil
@@ -249,3 +343,25 @@ func (prj *Project) consistentPrj(premises, results []*Goal) error {
 	}
 	return nil
 }
+
+// SubGoal is the artefact of a goal that stands for Goal from a sub-project,
+// see [Project.SubGoal].
+type SubGoal struct{ Goal *Goal }
+
+var _ HashableArtefact = SubGoal{}
+
+func (s SubGoal) Key() any { return s }
+
+// Name returns the name of Goal prefixed with its project's path relative to
+// in.
+func (s SubGoal) Name(in *Project) string {
+	return path.Join(filepath.ToSlash(s.Goal.Project().Name(in)), s.Goal.Name())
+}
+
+func (s SubGoal) StateAt(*Project) (time.Time, error) {
+	return s.Goal.Artefact.StateAt(s.Goal.Project())
+}
+
+func (s SubGoal) WriteHash(h hash.Hash, _ *Project) (bool, error) {
+	return s.Goal.WriteHash(h)
+}
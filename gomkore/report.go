@@ -0,0 +1,258 @@
+package gomkore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// BuildStatus is the outcome of a goal or an action in a [BuildReport].
+type BuildStatus int
+
+const (
+	// The goal was up-to-date, no action had to run.
+	StatusUpToDate BuildStatus = iota + 1
+
+	// The goal was updated or the action ran successfully.
+	StatusRebuilt
+
+	// The goal or the action failed.
+	StatusFailed
+
+	// The goal was not updated because a premise failed or the build stopped.
+	// The action was not run because the build stopped.
+	StatusSkipped
+
+	// The action failed but its error was ignored, see [Action.IgnoreError].
+	StatusIgnoredError
+
+	// The action was running when the build was interrupted.
+	StatusInterrupted
+)
+
+var buildStatusNames = []string{
+	"",
+	"up-to-date",
+	"rebuilt",
+	"failed",
+	"skipped",
+	"ignored-error",
+	"interrupted",
+}
+
+func (s BuildStatus) String() string {
+	if s > 0 && int(s) < len(buildStatusNames) {
+		return buildStatusNames[s]
+	}
+	return fmt.Sprintf("BuildStatus(%d)", int(s))
+}
+
+func (s BuildStatus) MarshalText() ([]byte, error) {
+	if s <= 0 || int(s) >= len(buildStatusNames) {
+		return nil, fmt.Errorf("invalid build status %d", int(s))
+	}
+	return []byte(buildStatusNames[s]), nil
+}
+
+func (s *BuildStatus) UnmarshalText(text []byte) error {
+	for i, n := range buildStatusNames[1:] {
+		if n == string(text) {
+			*s = BuildStatus(i + 1)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid build status '%s'", text)
+}
+
+// BuildReport describes what a [Builder] did in its last build, see
+// [Builder.Report]. Goals and actions are listed in the order they were
+// finished. Actions that were visited but not run are reported as up-to-date
+// or skipped. Implicit actions are not reported. Goals and actions of
+// sub-projects built by a Builder operation are part of the parent's report.
+type BuildReport struct {
+	Start   time.Time      `json:"start"`
+	End     time.Time      `json:"end"`
+	Error   string         `json:"error,omitempty"`
+	Goals   []GoalReport   `json:"goals"`
+	Actions []ActionReport `json:"actions"`
+
+	mu      sync.Mutex
+	actions map[*Action]int
+	reasons map[*Action]Schedule
+}
+
+// GoalReport is the entry of a goal in a [BuildReport].
+type GoalReport struct {
+	Project string      `json:"project"`
+	Build   BuildID     `json:"build"`
+	Name    string      `json:"name"`
+	Status  BuildStatus `json:"status"`
+	Reason  string      `json:"reason,omitempty"`
+	Start   time.Time   `json:"start"`
+	End     time.Time   `json:"end"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// ActionReport is the entry of an action in a [BuildReport].
+type ActionReport struct {
+	Project string      `json:"project"`
+	Build   BuildID     `json:"build"`
+	Action  string      `json:"action"`
+	Results []string    `json:"results"`
+	Status  BuildStatus `json:"status"`
+	Reason  string      `json:"reason,omitempty"`
+	Start   time.Time   `json:"start"`
+	End     time.Time   `json:"end"`
+	Error   string      `json:"error,omitempty"`
+}
+
+func newBuildReport() *BuildReport {
+	return &BuildReport{
+		Start:   time.Now(),
+		actions: make(map[*Action]int),
+		reasons: make(map[*Action]Schedule),
+	}
+}
+
+// WriteJSON writes r as indented JSON to w.
+func (r *BuildReport) WriteJSON(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func (r *BuildReport) done(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.End = time.Now()
+	r.Error = errString(err)
+}
+
+func (r *BuildReport) schedule(scheds []Schedule) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range scheds {
+		if _, ok := r.reasons[s.Action]; !ok {
+			r.reasons[s.Action] = s
+		}
+	}
+}
+
+func (r *BuildReport) goal(g *Goal, st BuildStatus, reason string, start time.Time, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Goals = append(r.Goals, GoalReport{
+		Project: g.Project().Dir,
+		Build:   g.Project().Build(),
+		Name:    g.Name(),
+		Status:  st,
+		Reason:  reason,
+		Start:   start,
+		End:     time.Now(),
+		Error:   errString(err),
+	})
+}
+
+func (r *BuildReport) action(tr *Trace, a *Action, start time.Time, err error) {
+	if r == nil || a.Op == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	idx, ok := r.actions[a]
+	if ok && r.Actions[idx].Status != StatusUpToDate {
+		return
+	}
+	var aerr *ActionError
+	if errors.As(err, &aerr) {
+		err = aerr.Err
+	}
+	ar := ActionReport{
+		Project: a.Project().Dir,
+		Build:   a.Project().Build(),
+		Action:  a.String(),
+		Status:  StatusRebuilt,
+		Start:   start,
+		End:     time.Now(),
+		Error:   errString(err),
+	}
+	for _, res := range a.Results() {
+		ar.Results = append(ar.Results, res.Name())
+	}
+	if s, ok := r.reasons[a]; ok {
+		ar.Reason = s.Reason.String()
+		if s.Premise != nil {
+			ar.Reason += " " + s.Premise.Name()
+		}
+	}
+	switch {
+	case errors.Is(err, errJobsStopped):
+		ar.Status, ar.Error = StatusSkipped, ""
+	case err != nil && tr.Ctx().Err() != nil:
+		ar.Status = StatusInterrupted
+	case err != nil:
+		ar.Status = StatusFailed
+	case a.lastErr != nil:
+		ar.Status, ar.Error = StatusIgnoredError, a.lastErr.Error()
+	}
+	if ok {
+		r.Actions[idx] = ar
+	} else {
+		r.actions[a] = len(r.Actions)
+		r.Actions = append(r.Actions, ar)
+	}
+}
+
+// notRun records actions that were visited but not run with status st. They
+// are replaced if the action runs later for another result.
+func (r *BuildReport) notRun(acts []*Action, st BuildStatus, reason string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, a := range acts {
+		if a.Op == nil {
+			continue
+		}
+		if _, ok := r.actions[a]; ok {
+			continue
+		}
+		ar := ActionReport{
+			Project: a.Project().Dir,
+			Build:   a.Project().Build(),
+			Action:  a.String(),
+			Status:  st,
+			Reason:  reason,
+			Start:   now,
+			End:     now,
+		}
+		for _, res := range a.Results() {
+			ar.Results = append(ar.Results, res.Name())
+		}
+		r.actions[a] = len(r.Actions)
+		r.Actions = append(r.Actions, ar)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
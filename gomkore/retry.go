@@ -0,0 +1,87 @@
+package gomkore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrActionTimeout is wrapped by the error of an action whose operation did
+// not complete within [Action.Timeout].
+var ErrActionTimeout = errors.New("action timed out")
+
+// RetryPolicy tells whether and when an [Action] is run again after its
+// operation failed.
+type RetryPolicy struct {
+	// Count is the maximum number of retries after the first failed run.
+	Count int
+
+	// Backoff is the delay before the first retry. It doubles with each further
+	// retry.
+	Backoff time.Duration
+
+	// MaxBackoff limits the delay between retries if > 0.
+	MaxBackoff time.Duration
+
+	// If decides whether an error is retried. If nil, all errors are retried.
+	// Nothing is retried after the context of the build was cancelled.
+	If func(error) bool
+}
+
+// Delay returns the delay before retry number i, starting with 0.
+func (rp *RetryPolicy) Delay(i int) time.Duration {
+	d := rp.Backoff
+	for ; i > 0 && d > 0; i-- {
+		if rp.MaxBackoff > 0 && d >= rp.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if rp.MaxBackoff > 0 && d > rp.MaxBackoff {
+		d = rp.MaxBackoff
+	}
+	return d
+}
+
+func (rp *RetryPolicy) retry(tr *Trace, i int, err error) bool {
+	if rp == nil || i >= rp.Count || tr.Ctx().Err() != nil {
+		return false
+	}
+	return rp.If == nil || rp.If(err)
+}
+
+func (a *Action) do(tr *Trace, env *Env) error {
+	for i := 0; ; i++ {
+		err := a.doOnce(tr, env)
+		if err == nil || !a.Retry.retry(tr, i, err) {
+			return err
+		}
+		d := a.Retry.Delay(i)
+		tr.Warn("retrying `action` in `delay` after `error`",
+			`action`, a,
+			`delay`, d,
+			`error`, err,
+		)
+		tm := time.NewTimer(d)
+		select {
+		case <-tr.Ctx().Done():
+			tm.Stop()
+			return err
+		case <-tm.C:
+		}
+	}
+}
+
+func (a *Action) doOnce(tr *Trace, env *Env) error {
+	if a.Timeout <= 0 {
+		return a.Op.Do(tr, a, env)
+	}
+	ctx, cancel := context.WithTimeout(tr.Ctx(), a.Timeout)
+	defer cancel()
+	err := a.Op.Do(tr.withContext(ctx), a, env)
+	if err != nil && tr.Ctx().Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %w", ErrActionTimeout, a.Timeout, err)
+	}
+	return err
+}
@@ -0,0 +1,306 @@
+package gomkore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// StateFile is the default name of the state file in a project's
+	// directory, see [OpenState].
+	StateFile = ".gomk-state.json"
+
+	// StateVersion is the version of the state file format.
+	StateVersion = 1
+
+	stateLockTimeout = 30 * time.Second
+	stateLockStale   = 5 * time.Minute
+)
+
+// ActionState is the persistent state of an [Action] from previous builds.
+type ActionState struct {
+	// Hash is the fingerprint of the action's last run, see
+	// [Action.Fingerprint].
+	Hash []byte `json:"hash,omitempty"`
+
+	// LastRun is the time when the action last completed successfully.
+	LastRun time.Time `json:"last-run"`
+
+	// Duration is the duration of the action's last successful run.
+	Duration time.Duration `json:"duration"`
+
+	// Results are the content digests of the action's results after the last
+	// successful run, keyed by goal name.
+	Results map[string][]byte `json:"results,omitempty"`
+
+	// Error is the error of the action's last run if it failed, see
+	// [Action.LastError].
+	Error string `json:"error,omitempty"`
+}
+
+// State persists the state of a project's actions between builds in a file.
+// A [Builder] restores fingerprints from and records the outcome of actions to
+// the state of a [Project] if it is set. Saving a state merges it with the
+// current content of the file under a lock file, so concurrent builds of the
+// same project do not lose each other's updates. The file and its lock are
+// accessed with the [FileSystem] of the project the state was opened for.
+type State struct {
+	path string
+	fsys FileSystem
+	now  func() time.Time
+
+	mu      sync.Mutex
+	actions map[string]ActionState
+	dirty   map[string]bool
+}
+
+type stateFile struct {
+	Version int                    `json:"version"`
+	Actions map[string]ActionState `json:"actions"`
+}
+
+// OpenState loads the state of prj from file, which is relative to the
+// project's directory. If file is empty, [StateFile] is used. A missing file
+// results in an empty state.
+func OpenState(prj *Project, file string) (*State, error) {
+	if file == "" {
+		file = StateFile
+	}
+	path, err := prj.AbsPath(file)
+	if err != nil {
+		return nil, err
+	}
+	fsys := prj.FileSystem()
+	sf, err := readStateFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return &State{
+		path:    path,
+		fsys:    fsys,
+		now:     prj.Now,
+		actions: sf.Actions,
+		dirty:   make(map[string]bool),
+	}, nil
+}
+
+// Path returns the absolute path of st's file.
+func (st *State) Path() string { return st.path }
+
+// Action returns the persistent state of a, if there is one.
+func (st *State) Action(a *Action) (ActionState, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	as, ok := st.actions[stateKey(a)]
+	return as, ok
+}
+
+// Save writes all changes of st to its file.
+func (st *State) Save() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if len(st.dirty) == 0 {
+		return nil
+	}
+	unlock, err := lockStateFile(st.fsys, st.path, st.now)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	sf, err := readStateFile(st.fsys, st.path)
+	if err != nil {
+		return err
+	}
+	for k := range st.dirty {
+		sf.Actions[k] = st.actions[k]
+	}
+	if err := writeStateFile(st.fsys, st.path, sf); err != nil {
+		return err
+	}
+	st.actions = sf.Actions
+	clear(st.dirty)
+	return nil
+}
+
+// restore sets the errors of all actions in prj that did not run yet and, if
+// hashes is set, the fingerprints of all actions that have none.
+func (st *State) restore(prj *Project, hashes bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, a := range prj.Actions() {
+		if a.Op == nil {
+			continue
+		}
+		as, ok := st.actions[stateKey(a)]
+		if !ok {
+			continue
+		}
+		if hashes && a.hash == nil {
+			a.hash = as.Hash
+		}
+		if a.lastBID == 0 && a.lastErr == nil && as.Error != "" {
+			a.lastErr = errors.New(as.Error)
+		}
+	}
+}
+
+// record updates st from all actions of prj that changed in build bid.
+func (st *State) record(prj *Project, bid BuildID) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, a := range prj.Actions() {
+		if a.Op == nil {
+			continue
+		}
+		key := stateKey(a)
+		as, chg := st.actions[key], false
+		if a.hash != nil && !bytes.Equal(as.Hash, a.hash) {
+			as.Hash = a.hash
+			chg = true
+		}
+		if a.lastBID == bid && a.lastErr != nil {
+			if e := a.lastErr.Error(); as.Error != e {
+				as.Error = e
+				chg = true
+			}
+		}
+		if a.doneBID == bid {
+			as.LastRun, as.Duration, as.Error = a.doneAt, a.doneDur, ""
+			as.Results = nil
+			for _, res := range a.Results() {
+				h := sha256.New()
+				if ok, err := res.WriteHash(h); err != nil {
+					return err
+				} else if ok {
+					if as.Results == nil {
+						as.Results = make(map[string][]byte)
+					}
+					as.Results[res.Name()] = h.Sum(nil)
+				}
+			}
+			chg = true
+		}
+		if chg {
+			st.actions[key] = as
+			st.dirty[key] = true
+		}
+	}
+	return nil
+}
+
+// stateKey identifies a by its results, its premises and its position in the
+// actions of its first result.
+func stateKey(a *Action) string {
+	var sb strings.Builder
+	for i, res := range a.Results() {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(res.Name())
+	}
+	sb.WriteString("<-")
+	for i, pre := range a.Premises() {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(pre.Name())
+	}
+	fmt.Fprintf(&sb, "#%d", slices.Index(a.Result(0).ResultOf(), a))
+	return sb.String()
+}
+
+func readStateFile(fsys FileSystem, path string) (sf stateFile, err error) {
+	var data []byte
+	r, err := fsys.Open(path)
+	if err == nil {
+		data, err = io.ReadAll(r)
+		if e := r.Close(); err == nil {
+			err = e
+		}
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		sf.Version = StateVersion
+	case err != nil:
+		return sf, err
+	default:
+		if err = json.Unmarshal(data, &sf); err != nil {
+			return sf, fmt.Errorf("state file %s: %w", path, err)
+		}
+		if sf.Version != StateVersion {
+			return sf, fmt.Errorf("state file %s has unsupported version %d",
+				path,
+				sf.Version,
+			)
+		}
+	}
+	if sf.Actions == nil {
+		sf.Actions = make(map[string]ActionState)
+	}
+	return sf, nil
+}
+
+func writeStateFile(fsys FileSystem, path string, sf stateFile) error {
+	tmp, w, err := createStateTemp(fsys, path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(sf)
+	if e := w.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = fsys.Rename(tmp, path)
+	}
+	if err != nil {
+		fsys.Remove(tmp)
+	}
+	return err
+}
+
+func createStateTemp(fsys FileSystem, path string) (string, io.WriteCloser, error) {
+	for {
+		tmp := fmt.Sprintf("%s.%d-%d", path, os.Getpid(), rand.Uint32())
+		w, err := fsys.CreateNew(tmp, 0666)
+		if !errors.Is(err, fs.ErrExist) {
+			return tmp, w, err
+		}
+	}
+}
+
+func lockStateFile(fsys FileSystem, path string, now func() time.Time) (unlock func(), err error) {
+	lock := path + ".lock"
+	deadline := time.Now().Add(stateLockTimeout)
+	for {
+		w, err := fsys.CreateNew(lock, 0666)
+		if err == nil {
+			fmt.Fprintln(w, os.Getpid())
+			w.Close()
+			return func() { fsys.Remove(lock) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if st, err := fsys.Stat(lock); err == nil && now().Sub(st.ModTime()) > stateLockStale {
+			fsys.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for state lock %s", lock)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,56 @@
+package gomkore
+
+import (
+	"hash"
+	"testing"
+	"time"
+)
+
+type nopOp struct{}
+
+func (nopOp) Describe(*Action, *Env) string                    { return "nop" }
+func (nopOp) Do(*Trace, *Action, *Env) error                   { return nil }
+func (nopOp) WriteHash(hash.Hash, *Action, *Env) (bool, error) { return true, nil }
+
+func TestState_Save_merge(t *testing.T) {
+	prj := NewProject(t.TempDir())
+	a, _ := prj.Goal(Abstract("a"))
+	b, _ := prj.Goal(Abstract("b"))
+	actA, _ := prj.NewAction(nil, []*Goal{a}, nopOp{})
+	actB, _ := prj.NewAction(nil, []*Goal{b}, nopOp{})
+
+	st1, err := OpenState(prj, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st2, err := OpenState(prj, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actA.hash, actA.doneBID, actA.doneAt = []byte("A"), 1, time.Now()
+	if err := st1.record(prj, 1); err != nil {
+		t.Fatal(err)
+	}
+	actA.hash, actA.doneBID = nil, 0
+	actB.hash, actB.doneBID, actB.doneAt = []byte("B"), 1, time.Now()
+	if err := st2.record(prj, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := st1.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := st2.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := OpenState(prj, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if as, ok := st.Action(actA); !ok || string(as.Hash) != "A" {
+		t.Errorf("lost state of action a: %+v", as)
+	}
+	if as, ok := st.Action(actB); !ok || string(as.Hash) != "B" {
+		t.Errorf("lost state of action b: %+v", as)
+	}
+}
@@ -3,6 +3,7 @@ package gomkore
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -30,27 +31,42 @@ type Trace struct {
 	up   *Trace
 	obj  any
 	id   uint64
+	ctx  context.Context
+
+	updater *updater // that runs the current action, see [Builder.Do]
 }
 
 func NewTrace(ctx context.Context, t Tracer) *Trace {
-	root := &traceRoot{ctx: ctx, tr: t}
-	return &Trace{root: root}
+	root := &traceRoot{tr: t}
+	return &Trace{root: root, ctx: ctx}
 }
 
-func (t *Trace) Ctx() context.Context { return t.root.ctx }
+// Ctx returns the context of t. It is the context passed to [NewTrace] unless
+// it was replaced for an element of the trace, e.g. for [Action.Timeout].
+func (t *Trace) Ctx() context.Context { return t.ctx }
+
+func (t *Trace) withContext(ctx context.Context) *Trace {
+	res := *t
+	res.ctx = ctx
+	return &res
+}
+
+func (t *Trace) withUpdater(up *updater) *Trace {
+	res := *t
+	res.updater = up
+	return &res
+}
 
 func (t *Trace) Debug(msg string, args ...any) { t.root.tr.Debug(t, msg, args...) }
 func (t *Trace) Info(msg string, args ...any)  { t.root.tr.Info(t, msg, args...) }
 func (t *Trace) Warn(msg string, args ...any)  { t.root.tr.Warn(t, msg, args...) }
 
 func (t *Trace) startProject(p *Project, activity string) {
-	t.root.prj = p
 	t.root.tr.StartProject(t, p, activity)
 }
 
 func (t *Trace) doneProject(p *Project, activity string, dt time.Duration) {
 	t.root.tr.DoneProject(t, p, activity, dt)
-	t.root.prj = nil
 }
 
 func (t *Trace) runAction(a *Action) {
@@ -61,6 +77,29 @@ func (t *Trace) runImplicitAction(a *Action) {
 	t.root.tr.RunImplicitAction(t, a)
 }
 
+func (t *Trace) actionDone(a *Action, dt time.Duration, err error) {
+	t.root.tr.ActionDone(t, a, dt, err)
+}
+
+func (t *Trace) goalDone(g *Goal, dt time.Duration, err error) {
+	t.root.tr.GoalDone(t, g, dt, err)
+}
+
+func (t *Trace) schedule(s Schedule) {
+	switch s.Reason {
+	case SchedResTimeZero:
+		t.scheduleResTimeZero(s.Action, s.Goal)
+	case SchedNoPremises:
+		t.scheduleNotPremises(s.Action, s.Goal)
+	case SchedPreTimeZero:
+		t.schedulePreTimeZero(s.Action, s.Goal, s.Premise)
+	case SchedOutdated, SchedPrePlanned:
+		t.scheduleOutdated(s.Action, s.Goal, s.Premise)
+	case SchedHashChanged:
+		t.scheduleHashChanged(s.Action, s.Goal)
+	}
+}
+
 func (t *Trace) scheduleResTimeZero(a *Action, res *Goal) {
 	t.root.tr.ScheduleResTimeZero(t, a, res)
 }
@@ -77,6 +116,10 @@ func (t *Trace) scheduleOutdated(a *Action, res, pre *Goal) {
 	t.root.tr.ScheduleOutdated(t, a, res, pre)
 }
 
+func (t *Trace) scheduleHashChanged(a *Action, res *Goal) {
+	t.root.tr.ScheduleHashChanged(t, a, res)
+}
+
 func (t *Trace) checkGoal(g *Goal) {
 	t.root.tr.CheckGoal(t, g)
 }
@@ -93,13 +136,36 @@ func (t *Trace) removeArtefact(g *Goal) {
 	t.root.tr.RemoveArtefact(t, g)
 }
 
+// Build returns the current build of the project of the innermost project,
+// goal or action on t's stack. Without such an element 0 is returned.
 func (t *Trace) Build() BuildID {
-	if t.up == nil {
-		return 0
+	if prj := t.project(); prj != nil {
+		return prj.Build()
+	}
+	return 0
+}
+
+func (t *Trace) project() *Project {
+	for ; t != nil; t = t.up {
+		switch o := t.obj.(type) {
+		case *Project:
+			return o
+		case *Goal:
+			return o.Project()
+		case *Action:
+			return o.Project()
+		}
 	}
-	return t.root.prj.Build()
+	return nil
 }
 
+// Top returns the innermost element of t, which is a *Project, *Goal or
+// *Action. It is nil for the root of a trace.
+func (t *Trace) Top() any { return t.obj }
+
+// Up returns the enclosing trace of t, nil for the root of a trace.
+func (t *Trace) Up() *Trace { return t.up }
+
 func (t *Trace) TopID() uint64 { return t.id }
 
 func (t *Trace) TopTag() string {
@@ -126,11 +192,25 @@ func (t *Trace) Path() string {
 	return sb.String()
 }
 
+// GoalPath returns the names of all goals on t's stack, from the outermost to
+// the innermost goal.
+func (t *Trace) GoalPath() string {
+	var gs []string
+	for ; t != nil; t = t.up {
+		if g, ok := t.obj.(*Goal); ok {
+			gs = append(gs, g.Name())
+		}
+	}
+	slices.Reverse(gs)
+	return strings.Join(gs, " > ")
+}
+
 func (t *Trace) String() string {
-	if t.root.prj == nil {
+	prj := t.project()
+	if prj == nil {
 		return t.Path()
 	}
-	return fmt.Sprintf("%d@%s", t.root.prj.Build(), t.Path())
+	return fmt.Sprintf("%d@%s", prj.Build(), t.Path())
 }
 
 func (t *Trace) pushProject(p *Project) *Trace {
@@ -139,6 +219,9 @@ func (t *Trace) pushProject(p *Project) *Trace {
 		up:   t,
 		obj:  p,
 		id:   t.root.idSeq.Add(1),
+		ctx:  t.ctx,
+
+		updater: t.updater,
 	}
 }
 
@@ -148,6 +231,21 @@ func (t *Trace) pushGoal(g *Goal) *Trace {
 		up:   t,
 		obj:  g,
 		id:   t.root.idSeq.Add(1),
+		ctx:  t.ctx,
+
+		updater: t.updater,
+	}
+}
+
+func (t *Trace) pushAction(a *Action) *Trace {
+	return &Trace{
+		root: t.root,
+		up:   t,
+		obj:  a,
+		id:   t.root.idSeq.Add(1),
+		ctx:  t.ctx,
+
+		updater: t.updater,
 	}
 }
 
@@ -160,8 +258,6 @@ func (t *Trace) closeActionEnv(env *Env) error {
 }
 
 type traceRoot struct {
-	ctx   context.Context
 	tr    Tracer
-	prj   *Project
 	idSeq atomic.Uint64
 }
@@ -1,33 +1,110 @@
 package gomkore
 
 import (
+	"context"
 	"fmt"
 	"slices"
+	"sync"
+	"time"
 	"unsafe"
 )
 
 type updater struct {
-	trace *Trace
-	env   *Env
-	bid   BuildID // => updater must not be used concurrently
+	trace  *Trace
+	env    *Env
+	bid    BuildID // => updater must not be used concurrently
+	jobs   *jobs
+	hashes bool
+	keepOn bool // keep going after failed goals, see [Builder.KeepGoing]
+	plan   *Plan
+	report *BuildReport
+
+	intrMu      sync.Mutex
+	interrupted []*Action
 }
 
 func (up *updater) Trace() *Trace { return up.trace }
 
+func (up *updater) activity(act string) string {
+	if up.plan != nil {
+		return "planning"
+	}
+	return act
+}
+
+func (up *updater) run(tr *Trace, g *Goal, act *Action) (bid BuildID, err error) {
+	start := time.Now()
+	defer func() { up.report.action(tr, act, start, err) }()
+	if tr.Ctx().Err() != nil || !up.jobs.acquire() {
+		return 0, errJobsStopped
+	}
+	defer up.jobs.release()
+	if tr.Ctx().Err() != nil {
+		return 0, errJobsStopped
+	}
+	start = time.Now()
+	bid, err = act.Run(tr.withUpdater(up), up.env)
+	if err != nil {
+		if tr.Ctx().Err() != nil {
+			up.interrupt(tr, act)
+		}
+		err = &ActionError{
+			Action: act,
+			Goal:   g,
+			Path:   tr.GoalPath(),
+			Err:    err,
+			Output: act.lastOut,
+		}
+	}
+	return bid, err
+}
+
+func (up *updater) interrupt(tr *Trace, act *Action) {
+	tr.Warn("interrupted `action`", `action`, act)
+	up.intrMu.Lock()
+	defer up.intrMu.Unlock()
+	up.interrupted = append(up.interrupted, act)
+}
+
+// interruption replaces err with an [InterruptedError] if the context of tr
+// was cancelled.
+func (up *updater) interruption(tr *Trace, err error) error {
+	if err == nil || tr.Ctx().Err() == nil {
+		return err
+	}
+	up.intrMu.Lock()
+	defer up.intrMu.Unlock()
+	return &InterruptedError{
+		Cause:   context.Cause(tr.Ctx()),
+		Actions: up.interrupted,
+	}
+}
+
 func (up *updater) updateGoal(tr *Trace, g *Goal) (bool, error) {
 	gid := uintptr(unsafe.Pointer(g))
 	g.LockPreActions(gid)
 	defer g.UnlockPreActions()
 
-	chgs, err := g.CheckPreTimes(tr)
+	chgs, scheds, err := g.checkPre(tr, up.env, up.hashes, up.plan)
 	if err != nil {
 		return false, err
 	}
+	var upToDate []*Action
+	for i, act := range g.ResultOf() {
+		if slices.Index(chgs, i) < 0 {
+			upToDate = append(upToDate, act)
+		}
+	}
+	up.report.notRun(upToDate, StatusUpToDate, "")
 	if len(chgs) == 0 {
 		tr.goalUpToDate(g)
 		return false, nil
 	}
 	tr.goalNeedsActions(g, len(chgs))
+	up.report.schedule(scheds)
+	if up.plan != nil {
+		return true, up.plan.update(g, scheds)
+	}
 
 	switch g.UpdateMode.Actions() {
 	case UpdAllActions:
@@ -57,7 +134,7 @@ func (up *updater) updateAll(tr *Trace, g *Goal, _ []int) error {
 		return nil
 	case 1:
 		act := g.PreAction(0)
-		preBID, err := act.Run(tr, up.env)
+		preBID, err := up.run(tr, g, act)
 		if err != nil {
 			return err
 		} else if preBID > up.bid {
@@ -70,7 +147,7 @@ func (up *updater) updateAll(tr *Trace, g *Goal, _ []int) error {
 	}
 	if g.UpdateMode.Ordered() {
 		for _, act := range g.ResultOf() {
-			if preBID, err := act.Run(tr, up.env); err != nil {
+			if preBID, err := up.run(tr, g, act); err != nil {
 				return err
 			} else if preBID == up.bid {
 				return fmt.Errorf("action %s potentially ran out of order", act)
@@ -82,8 +159,9 @@ func (up *updater) updateAll(tr *Trace, g *Goal, _ []int) error {
 			}
 		}
 	} else {
-		for _, act := range g.ResultOf() {
-			if preBID, err := act.Run(tr, up.env); err != nil {
+		return up.jobs.each(len(g.ResultOf()), func(i int) error {
+			act := g.PreAction(i)
+			if preBID, err := up.run(tr, g, act); err != nil {
 				return err
 			} else if preBID > up.bid {
 				return fmt.Errorf("action %s already run by younger build %d",
@@ -91,7 +169,8 @@ func (up *updater) updateAll(tr *Trace, g *Goal, _ []int) error {
 					preBID,
 				)
 			}
-		}
+			return nil
+		})
 	}
 	return nil
 }
@@ -100,7 +179,7 @@ func (up *updater) updateSome(tr *Trace, g *Goal, chgs []int) error {
 	if len(chgs) > 1 && g.UpdateMode.Ordered() {
 		for _, idx := range chgs {
 			act := g.PreAction(idx)
-			if preBID, err := act.Run(tr, up.env); err != nil {
+			if preBID, err := up.run(tr, g, act); err != nil {
 				return err
 			} else if preBID == up.bid {
 				return fmt.Errorf("action %s potentially ran out of order", act)
@@ -112,9 +191,9 @@ func (up *updater) updateSome(tr *Trace, g *Goal, chgs []int) error {
 			}
 		}
 	} else {
-		for _, idx := range chgs {
-			act := g.PreAction(idx)
-			if preBID, err := act.Run(tr, up.env); err != nil {
+		return up.jobs.each(len(chgs), func(i int) error {
+			act := g.PreAction(chgs[i])
+			if preBID, err := up.run(tr, g, act); err != nil {
 				return err
 			} else if preBID > up.bid {
 				return fmt.Errorf("action %s already run by younger build %d",
@@ -122,10 +201,10 @@ func (up *updater) updateSome(tr *Trace, g *Goal, chgs []int) error {
 					preBID,
 				)
 			}
-		}
+			return nil
+		})
 	}
 	return nil
-
 }
 
 func (up *updater) updateAny(tr *Trace, g *Goal, chgs []int) error {
@@ -157,7 +236,7 @@ func (up *updater) updateAny(tr *Trace, g *Goal, chgs []int) error {
 	if done >= 0 {
 		return nil
 	}
-	_, err := g.PreAction(chgs[0]).Run(tr, up.env)
+	_, err := up.run(tr, g, g.PreAction(chgs[0]))
 	return err
 }
 
@@ -181,6 +260,6 @@ func (up *updater) updateOne(tr *Trace, g *Goal, chg int) error {
 			}
 		}
 	}
-	_, err := g.PreAction(chg).Run(tr, up.env)
+	_, err := up.run(tr, g, g.PreAction(chg))
 	return err
 }
@@ -0,0 +1,84 @@
+package gomkore
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CycleError reports a dependency cycle of goals. The first and the last goal
+// of the cycle are the same.
+type CycleError []*Goal
+
+func (e CycleError) Error() string {
+	names := make([]string, len(e))
+	for i, g := range e {
+		names[i] = g.Name()
+	}
+	return "dependency cycle: " + strings.Join(names, " > ")
+}
+
+// Validate checks the goal graph of prj. It reports
+//   - dependency cycles as [CycleError],
+//   - abstract goals that are not the result of any action,
+//   - goals with update mode [UpdOneAction] that have several actions and
+//   - results of actions that violate [Goal.UpdateConsistency].
+//
+// All problems are joined into the returned error.
+func (prj *Project) Validate() error {
+	gs := prj.Goals(nil)
+	slices.SortFunc(gs, func(g, h *Goal) int { return strings.Compare(g.Name(), h.Name()) })
+	var errs []error
+	for _, g := range gs {
+		if g.IsAbstract() && len(g.ResultOf()) == 0 {
+			errs = append(errs, fmt.Errorf("abstract goal %s without actions", g))
+		}
+		if l := len(g.ResultOf()); l > 1 && g.UpdateMode.Actions() == UpdOneAction {
+			errs = append(errs, fmt.Errorf("goal %s with update mode One has %d actions", g, l))
+		}
+	}
+	for _, a := range prj.Actions() {
+		if err := updateConsistency(a.Results()); err != nil {
+			errs = append(errs, fmt.Errorf("action (%s): %w", a, err))
+		}
+	}
+	errs = append(errs, findCycles(gs)...)
+	return errors.Join(errs...)
+}
+
+func findCycles(gs []*Goal) (errs []error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	var (
+		state = make(map[*Goal]int)
+		path  []*Goal
+		visit func(g *Goal)
+	)
+	visit = func(g *Goal) {
+		switch state[g] {
+		case visiting:
+			i := slices.Index(path, g)
+			cycle := append(slices.Clone(path[i:]), g)
+			errs = append(errs, CycleError(cycle))
+			return
+		case visited:
+			return
+		}
+		state[g] = visiting
+		path = append(path, g)
+		for _, act := range g.ResultOf() {
+			for _, pre := range act.Premises() {
+				visit(pre)
+			}
+		}
+		path = path[:len(path)-1]
+		state[g] = visited
+	}
+	for _, g := range gs {
+		visit(g)
+	}
+	return errs
+}
@@ -0,0 +1,31 @@
+package gomkore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestProject_Validate(t *testing.T) {
+	prj := NewProject(t.Name())
+	a, _ := prj.Goal(Abstract("a"))
+	b, _ := prj.Goal(Abstract("b"))
+	c, _ := prj.Goal(Abstract("c"))
+	if _, err := prj.NewAction([]*Goal{b, c}, []*Goal{a}, nopOp{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prj.NewAction([]*Goal{a}, []*Goal{b}, nopOp{}); err != nil {
+		t.Fatal(err)
+	}
+	err := prj.Validate()
+	var cycle CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("no cycle error: %v", err)
+	}
+	if s := cycle.Error(); s != "dependency cycle: a > b > a" {
+		t.Errorf("unexpected cycle: %s", s)
+	}
+	if !strings.Contains(err.Error(), "abstract goal c:Abstract without actions") {
+		t.Errorf("missing abstract goal error: %v", err)
+	}
+}
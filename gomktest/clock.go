@@ -0,0 +1,49 @@
+package gomktest
+
+import (
+	"sync"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// Clock is a [gomkore.Clock] that only advances when told to. Set it as the
+// Clock of a project to get reproducible timestamps, also of the files in an
+// [mkfs.MemFS] mounted on the project. It is safe for concurrent use.
+//
+// [mkfs.MemFS]: https://pkg.go.dev/git.fractalqb.de/fractalqb/gomk/mkfs#MemFS
+type Clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+var _ gomkore.Clock = (*Clock)(nil)
+
+// NewClock returns a clock that is set to t, or to [Epoch] if t is zero.
+func NewClock(t time.Time) *Clock {
+	if t.IsZero() {
+		t = Epoch
+	}
+	return &Clock{t: t}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Set sets the clock to t.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+}
+
+// Advance moves the clock forward by d and returns the new time.
+func (c *Clock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+	return c.t
+}
@@ -0,0 +1,113 @@
+package gomktest
+
+import (
+	"slices"
+	"testing"
+)
+
+// Runs returns how often the action with description action was run.
+func (r *Recorder) Runs(action string) (n int) {
+	for _, e := range r.Filter(EvRunAction) {
+		if e.Action.String() == action {
+			n++
+		}
+	}
+	return n
+}
+
+// RunOrder returns the descriptions of all actions in the order they were
+// run. Implicit actions are not included.
+func (r *Recorder) RunOrder() (order []string) {
+	for _, e := range r.Filter(EvRunAction) {
+		order = append(order, e.Action.String())
+	}
+	return order
+}
+
+// GoalEvents returns the kinds of all events recorded for the goal named goal.
+func (r *Recorder) GoalEvents(goal string) (kinds []EventKind) {
+	for _, e := range r.Events() {
+		if e.Goal != nil && e.Goal.Name() == goal {
+			kinds = append(kinds, e.Kind)
+		}
+	}
+	return kinds
+}
+
+// ExpectUpToDate checks that all goals were found to be up-to-date.
+func (r *Recorder) ExpectUpToDate(t testing.TB, goals ...string) {
+	t.Helper()
+	for _, g := range goals {
+		if !slices.Contains(r.GoalEvents(g), EvGoalUpToDate) {
+			t.Errorf("goal [%s] was not up-to-date", g)
+		}
+	}
+}
+
+// ExpectUpdated checks that all goals needed actions to be updated.
+func (r *Recorder) ExpectUpdated(t testing.TB, goals ...string) {
+	t.Helper()
+	for _, g := range goals {
+		if !slices.Contains(r.GoalEvents(g), EvGoalNeedsActions) {
+			t.Errorf("goal [%s] was not updated", g)
+		}
+	}
+}
+
+// ExpectFailed checks that the updates of all goals failed.
+func (r *Recorder) ExpectFailed(t testing.TB, goals ...string) {
+	t.Helper()
+NEXT_GOAL:
+	for _, g := range goals {
+		for _, e := range r.Filter(EvGoalDone) {
+			if e.Goal.Name() == g && e.Err != nil {
+				continue NEXT_GOAL
+			}
+		}
+		t.Errorf("goal [%s] did not fail", g)
+	}
+}
+
+// ExpectRuns checks that action was run n times.
+func (r *Recorder) ExpectRuns(t testing.TB, action string, n int) {
+	t.Helper()
+	if m := r.Runs(action); m != n {
+		t.Errorf("action (%s) ran %d times, expected %d", action, m, n)
+	}
+}
+
+// ExpectRanOnce checks that each of the actions was run exactly once.
+func (r *Recorder) ExpectRanOnce(t testing.TB, actions ...string) {
+	t.Helper()
+	for _, a := range actions {
+		r.ExpectRuns(t, a, 1)
+	}
+}
+
+// ExpectNotRun checks that none of the actions was run.
+func (r *Recorder) ExpectNotRun(t testing.TB, actions ...string) {
+	t.Helper()
+	for _, a := range actions {
+		r.ExpectRuns(t, a, 0)
+	}
+}
+
+// ExpectOrder checks that the actions were run in the given order. Other
+// actions may have run in between.
+func (r *Recorder) ExpectOrder(t testing.TB, actions ...string) {
+	t.Helper()
+	order := r.RunOrder()
+	last := -1
+	for _, a := range actions {
+		i := slices.Index(order, a)
+		switch {
+		case i < 0:
+			t.Errorf("action (%s) did not run", a)
+			return
+		case i < last:
+			t.Errorf("action (%s) ran before (%s), order was %v", a, order[last], order)
+			return
+		}
+		last = i
+	}
+}
@@ -0,0 +1,76 @@
+package gomktest_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"git.fractalqb.de/fractalqb/gomk"
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/gomk/gomktest"
+	"git.fractalqb.de/fractalqb/gomk/mkfs"
+	"git.fractalqb.de/fractalqb/testerr"
+)
+
+func upper(_ *gomkore.Trace, a *gomkore.Action, _ *gomkore.Env) error {
+	prj := a.Project()
+	src, _ := prj.AbsPath(string(a.Premise(0).Artefact.(mkfs.File)))
+	dst, _ := prj.AbsPath(string(a.Result(0).Artefact.(mkfs.File)))
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, []byte(strings.ToUpper(string(data))), 0666)
+}
+
+func TestBuildScript(t *testing.T) {
+	prj := gomktest.TempProject(t, gomktest.File{
+		Path:    "src.txt",
+		Content: "hello",
+		MTime:   gomktest.Time(1),
+	})
+	testerr.Shall(gomk.Edit(prj, func(prj gomk.ProjectEd) {
+		src := prj.Goal(mkfs.File("src.txt"))
+		doc, _ := prj.Goal(mkfs.File("doc.txt")).By(gomk.OpFunc("upper", upper), src)
+		prj.AbstractGoal("all").By(gomk.OpFunc("announce", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			return nil
+		}), doc)
+	})).BeNil(t)
+
+	rec := testerr.Shall1(gomktest.Build(t, prj, nil)).BeNil(t)
+	rec.ExpectRanOnce(t, "upper", "announce")
+	rec.ExpectOrder(t, "upper", "announce")
+	rec.ExpectUpdated(t, "doc.txt", "all")
+	if s := gomktest.ReadFile(t, prj, "doc.txt"); s != "HELLO" {
+		t.Errorf("unexpected content '%s'", s)
+	}
+
+	rec = testerr.Shall1(gomktest.Build(t, prj, nil, "doc.txt")).BeNil(t)
+	rec.ExpectUpToDate(t, "doc.txt")
+	rec.ExpectNotRun(t, "upper", "announce")
+
+	gomktest.Touch(t, prj, "doc.txt", gomktest.Time(0))
+	rec = testerr.Shall1(gomktest.Build(t, prj, func(bd *gomkore.Builder) { bd.MaxJobs = 2 })).BeNil(t)
+	rec.ExpectUpdated(t, "doc.txt")
+	rec.ExpectRanOnce(t, "upper")
+
+	t.Run("failing expectations", func(t *testing.T) {
+		ft := &failT{TB: t}
+		rec.ExpectOrder(ft, "announce", "upper")
+		rec.ExpectUpToDate(ft, "doc.txt")
+		rec.ExpectFailed(ft, "all")
+		if len(ft.errs) != 3 {
+			t.Errorf("unexpected failures: %v", ft.errs)
+		}
+	})
+}
+
+type failT struct {
+	testing.TB
+	errs []string
+}
+
+func (t *failT) Errorf(format string, args ...any) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
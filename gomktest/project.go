@@ -0,0 +1,105 @@
+package gomktest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// Epoch is the base of the modification times set by gomktest, see [Time].
+var Epoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Time returns the time sec seconds after [Epoch]. Use it to give files
+// distinct modification times without sleeping.
+func Time(sec int) time.Time { return Epoch.Add(time.Duration(sec) * time.Second) }
+
+// File describes a file to be created by [TempProject] or [WriteFile].
+type File struct {
+	// Path is relative to the project directory and uses '/' as separator.
+	Path    string
+	Content string
+	// MTime is the modification time of the file. If it is zero, [Epoch] is
+	// used.
+	MTime time.Time
+}
+
+// TempProject creates a project in a new temporary directory of t with the
+// given files. The directory is removed when the test finishes.
+func TempProject(t testing.TB, files ...File) *gomkore.Project {
+	t.Helper()
+	prj := gomkore.NewProject(t.TempDir())
+	for _, f := range files {
+		WriteFile(t, prj, f)
+	}
+	return prj
+}
+
+// WriteFile creates or replaces file f in the directory of prj and sets its
+// modification time. Missing parent directories are created.
+func WriteFile(t testing.TB, prj *gomkore.Project, f File) {
+	t.Helper()
+	path := absPath(t, prj, f.Path)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(f.Content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	Touch(t, prj, f.Path, f.MTime)
+}
+
+// Touch sets the modification time of file in the directory of prj to mtime,
+// or to [Epoch] if mtime is zero.
+func Touch(t testing.TB, prj *gomkore.Project, file string, mtime time.Time) {
+	t.Helper()
+	if mtime.IsZero() {
+		mtime = Epoch
+	}
+	if err := os.Chtimes(absPath(t, prj, file), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ReadFile returns the content of file in the directory of prj.
+func ReadFile(t testing.TB, prj *gomkore.Project, file string) string {
+	t.Helper()
+	data, err := os.ReadFile(absPath(t, prj, file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func absPath(t testing.TB, prj *gomkore.Project, file string) string {
+	t.Helper()
+	path, err := prj.AbsPath(filepath.FromSlash(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Build builds the goals of prj with the given names, or the whole project if
+// no goal is given. It returns the recorded events and the build error. If
+// setup is not nil, it is called to configure the builder before the build.
+func Build(t testing.TB, prj *gomkore.Project, setup func(*gomkore.Builder), goals ...string) (*Recorder, error) {
+	t.Helper()
+	rec := NewRecorder(t)
+	bd, err := gomkore.NewBuilder(gomkore.NewTrace(context.Background(), rec), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setup != nil {
+		setup(bd)
+	}
+	if len(goals) == 0 {
+		err = bd.Project(prj)
+	} else {
+		err = bd.NamedGoals(prj, goals...)
+	}
+	return rec, err
+}
@@ -0,0 +1,234 @@
+// Package gomktest helps to test gomk build scripts like regular Go code. A
+// [Recorder] is a tracer that collects the events of builds, which then can be
+// checked with its Expect methods. [TempProject] sets up a project in a
+// temporary directory with files that have controlled modification times.
+package gomktest
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// EventKind identifies the tracer method that recorded an [Event].
+type EventKind int
+
+const (
+	EvDebug EventKind = iota + 1
+	EvInfo
+	EvWarn
+	EvStartProject
+	EvDoneProject
+	EvRunAction
+	EvRunImplicitAction
+	EvActionDone
+	EvScheduleResTimeZero
+	EvScheduleNotPremises
+	EvSchedulePreTimeZero
+	EvScheduleOutdated
+	EvScheduleHashChanged
+	EvCheckGoal
+	EvGoalUpToDate
+	EvGoalNeedsActions
+	EvGoalDone
+	EvRemoveArtefact
+)
+
+var eventKindNames = [...]string{
+	EvDebug:               "Debug",
+	EvInfo:                "Info",
+	EvWarn:                "Warn",
+	EvStartProject:        "StartProject",
+	EvDoneProject:         "DoneProject",
+	EvRunAction:           "RunAction",
+	EvRunImplicitAction:   "RunImplicitAction",
+	EvActionDone:          "ActionDone",
+	EvScheduleResTimeZero: "ScheduleResTimeZero",
+	EvScheduleNotPremises: "ScheduleNotPremises",
+	EvSchedulePreTimeZero: "SchedulePreTimeZero",
+	EvScheduleOutdated:    "ScheduleOutdated",
+	EvScheduleHashChanged: "ScheduleHashChanged",
+	EvCheckGoal:           "CheckGoal",
+	EvGoalUpToDate:        "GoalUpToDate",
+	EvGoalNeedsActions:    "GoalNeedsActions",
+	EvGoalDone:            "GoalDone",
+	EvRemoveArtefact:      "RemoveArtefact",
+}
+
+func (k EventKind) String() string {
+	if k > 0 && int(k) < len(eventKindNames) {
+		return eventKindNames[k]
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event is one recorded tracer event. Only the fields that belong to the
+// event's tracer method are set.
+type Event struct {
+	Kind  EventKind
+	Build gomkore.BuildID
+
+	Project  *gomkore.Project
+	Activity string
+
+	Action  *gomkore.Action
+	Goal    *gomkore.Goal
+	Premise *gomkore.Goal
+
+	// N is the number of actions for EvGoalNeedsActions.
+	N        int
+	Duration time.Duration
+	Err      error
+
+	Msg  string
+	Args []any
+}
+
+func (e Event) String() string {
+	switch {
+	case e.Action != nil && e.Goal != nil:
+		return fmt.Sprintf("%s (%s) [%s]", e.Kind, e.Action, e.Goal)
+	case e.Action != nil:
+		return fmt.Sprintf("%s (%s)", e.Kind, e.Action)
+	case e.Goal != nil:
+		return fmt.Sprintf("%s [%s]", e.Kind, e.Goal)
+	case e.Project != nil:
+		return fmt.Sprintf("%s %s '%s'", e.Kind, e.Activity, e.Project)
+	}
+	return fmt.Sprintf("%s %s", e.Kind, e.Msg)
+}
+
+// Recorder is a [gomkore.Tracer] that records all events. It can be used
+// concurrently. Its query and Expect methods identify goals by their name, see
+// [gomkore.Goal.Name], and actions by their description, see
+// [gomkore.Action.String].
+type Recorder struct {
+	// Log is used to log events if not nil.
+	Log testing.TB
+
+	mu     sync.Mutex
+	events []Event
+}
+
+var _ gomkore.Tracer = (*Recorder)(nil)
+
+// NewRecorder returns a recorder that logs events to t, which may be nil.
+func NewRecorder(t testing.TB) *Recorder { return &Recorder{Log: t} }
+
+// Events returns a copy of all recorded events.
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+// Filter returns all recorded events of the given kinds.
+func (r *Recorder) Filter(kinds ...EventKind) (evs []Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.events {
+		if slices.Contains(kinds, e.Kind) {
+			evs = append(evs, e)
+		}
+	}
+	return evs
+}
+
+// Reset discards all recorded events, e.g. between two builds.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
+
+func (r *Recorder) record(t *gomkore.Trace, e Event) {
+	e.Build = t.Build()
+	if r.Log != nil {
+		r.Log.Logf("gomktest: %d %s", e.Build, e)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *Recorder) Debug(t *gomkore.Trace, msg string, args ...any) {
+	r.record(t, Event{Kind: EvDebug, Msg: msg, Args: args})
+}
+
+func (r *Recorder) Info(t *gomkore.Trace, msg string, args ...any) {
+	r.record(t, Event{Kind: EvInfo, Msg: msg, Args: args})
+}
+
+func (r *Recorder) Warn(t *gomkore.Trace, msg string, args ...any) {
+	r.record(t, Event{Kind: EvWarn, Msg: msg, Args: args})
+}
+
+func (r *Recorder) StartProject(t *gomkore.Trace, p *gomkore.Project, activity string) {
+	r.record(t, Event{Kind: EvStartProject, Project: p, Activity: activity})
+}
+
+func (r *Recorder) DoneProject(t *gomkore.Trace, p *gomkore.Project, activity string, dt time.Duration) {
+	r.record(t, Event{Kind: EvDoneProject, Project: p, Activity: activity, Duration: dt})
+}
+
+func (r *Recorder) SetupActionEnv(t *gomkore.Trace, env *gomkore.Env) (*gomkore.Env, error) {
+	return env, nil
+}
+
+func (r *Recorder) CloseActionEnv(t *gomkore.Trace, env *gomkore.Env) error { return nil }
+
+func (r *Recorder) RunAction(t *gomkore.Trace, a *gomkore.Action) {
+	r.record(t, Event{Kind: EvRunAction, Action: a})
+}
+
+func (r *Recorder) RunImplicitAction(t *gomkore.Trace, a *gomkore.Action) {
+	r.record(t, Event{Kind: EvRunImplicitAction, Action: a})
+}
+
+func (r *Recorder) ActionDone(t *gomkore.Trace, a *gomkore.Action, dt time.Duration, err error) {
+	r.record(t, Event{Kind: EvActionDone, Action: a, Duration: dt, Err: err})
+}
+
+func (r *Recorder) ScheduleResTimeZero(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	r.record(t, Event{Kind: EvScheduleResTimeZero, Action: a, Goal: res})
+}
+
+func (r *Recorder) ScheduleNotPremises(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	r.record(t, Event{Kind: EvScheduleNotPremises, Action: a, Goal: res})
+}
+
+func (r *Recorder) SchedulePreTimeZero(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+	r.record(t, Event{Kind: EvSchedulePreTimeZero, Action: a, Goal: res, Premise: pre})
+}
+
+func (r *Recorder) ScheduleOutdated(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+	r.record(t, Event{Kind: EvScheduleOutdated, Action: a, Goal: res, Premise: pre})
+}
+
+func (r *Recorder) ScheduleHashChanged(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	r.record(t, Event{Kind: EvScheduleHashChanged, Action: a, Goal: res})
+}
+
+func (r *Recorder) CheckGoal(t *gomkore.Trace, g *gomkore.Goal) {
+	r.record(t, Event{Kind: EvCheckGoal, Goal: g})
+}
+
+func (r *Recorder) GoalUpToDate(t *gomkore.Trace, g *gomkore.Goal) {
+	r.record(t, Event{Kind: EvGoalUpToDate, Goal: g})
+}
+
+func (r *Recorder) GoalNeedsActions(t *gomkore.Trace, g *gomkore.Goal, n int) {
+	r.record(t, Event{Kind: EvGoalNeedsActions, Goal: g, N: n})
+}
+
+func (r *Recorder) GoalDone(t *gomkore.Trace, g *gomkore.Goal, dt time.Duration, err error) {
+	r.record(t, Event{Kind: EvGoalDone, Goal: g, Duration: dt, Err: err})
+}
+
+func (r *Recorder) RemoveArtefact(t *gomkore.Trace, g *gomkore.Goal) {
+	r.record(t, Event{Kind: EvRemoveArtefact, Goal: g})
+}
@@ -0,0 +1,88 @@
+package gomk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// WriteGraphML writes the graph of prj as GraphML to w for import into graph
+// tools such as yEd. Nodes have the data keys label, kind (abstract, tangible,
+// action or joint for implicit actions), artefact, update and state, see
+// [Diagrammer.Plan]. Edges have the data keys implicit, trigger and order, the
+// 1-based position of the action in the result's actions if the result has an
+// ordered update mode.
+func (dia *Diagrammer) WriteGraphML(w io.Writer, prj *gomkore.Project) (err error) {
+	defer recoverDiagram(&err)
+	g, err := dia.graph(prj)
+	if err != nil {
+		return err
+	}
+	writeGraphML(w, g)
+	return nil
+}
+
+func writeGraphML(w io.Writer, g *diaGraph) {
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintln(w, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(w, `  <key id="label" for="node" attr.name="label" attr.type="string"/>`)
+	fmt.Fprintln(w, `  <key id="kind" for="node" attr.name="kind" attr.type="string"/>`)
+	fmt.Fprintln(w, `  <key id="artefact" for="node" attr.name="artefact" attr.type="string"/>`)
+	fmt.Fprintln(w, `  <key id="update" for="node" attr.name="update" attr.type="string"/>`)
+	fmt.Fprintln(w, `  <key id="state" for="node" attr.name="state" attr.type="string"/>`)
+	fmt.Fprintln(w, `  <key id="implicit" for="edge" attr.name="implicit" attr.type="boolean">`)
+	fmt.Fprintln(w, `    <default>false</default>`)
+	fmt.Fprintln(w, `  </key>`)
+	fmt.Fprintln(w, `  <key id="order" for="edge" attr.name="order" attr.type="int">`)
+	fmt.Fprintln(w, `    <default>0</default>`)
+	fmt.Fprintln(w, `  </key>`)
+	fmt.Fprintln(w, `  <key id="trigger" for="edge" attr.name="trigger" attr.type="boolean">`)
+	fmt.Fprintln(w, `    <default>false</default>`)
+	fmt.Fprintln(w, `  </key>`)
+	fmt.Fprintf(w, "  <graph id=\"%s\" edgedefault=\"directed\">\n", escXML(g.name))
+	for _, n := range g.nodes {
+		fmt.Fprintf(w, "    <node id=\"%s\">\n", n.id)
+		if n.label != "" {
+			graphMLData(w, "label", n.label)
+		}
+		graphMLData(w, "kind", n.kind.String())
+		if n.atfType != "" {
+			graphMLData(w, "artefact", n.atfType)
+		}
+		if m := strings.TrimSpace(n.updMode); m != "" {
+			graphMLData(w, "update", m)
+		}
+		if n.state != diaNoState {
+			graphMLData(w, "state", n.state.String())
+		}
+		fmt.Fprintln(w, "    </node>")
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(w, "    <edge source=\"%s\" target=\"%s\">\n", e.from, e.to)
+		if e.implicit {
+			graphMLData(w, "implicit", "true")
+		}
+		if e.order > 0 {
+			graphMLData(w, "order", fmt.Sprint(e.order))
+		}
+		if e.trigger {
+			graphMLData(w, "trigger", "true")
+		}
+		fmt.Fprintln(w, "    </edge>")
+	}
+	fmt.Fprintln(w, "  </graph>")
+	fmt.Fprintln(w, "</graphml>")
+}
+
+func graphMLData(w io.Writer, key, value string) {
+	fmt.Fprintf(w, "      <data key=\"%s\">%s</data>\n", key, escXML(value))
+}
+
+func escXML(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
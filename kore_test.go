@@ -2,10 +2,20 @@ package gomk
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/gomk/gomktest"
 	"git.fractalqb.de/fractalqb/gomk/mkfs"
 	"git.fractalqb.de/fractalqb/testerr"
 )
@@ -56,3 +66,609 @@ func Test_buildProject(t *testing.T) {
 	testerr.Shall(build.Project(prj)).BeNil(t)
 	testerr.Shall1(os.Stat("testdata/prj/doc/foo.cp")).BeNil(t)
 }
+
+// concurrentJobs counts the runs of its operation and their maximum
+// concurrency.
+type concurrentJobs struct {
+	running, max, runs atomic.Int32
+}
+
+func (cj *concurrentJobs) op() gomkore.Operation {
+	return OpFunc("job", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+		n := cj.running.Add(1)
+		defer cj.running.Add(-1)
+		for m := cj.max.Load(); n > m; m = cj.max.Load() {
+			if cj.max.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		cj.runs.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+}
+
+func Test_buildParallel(t *testing.T) {
+	var cj concurrentJobs
+	op := cj.op()
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		var jobs []GoalEd
+		for i := range 4 {
+			g, _ := prj.AbstractGoal(fmt.Sprintf("job%d", i)).By(op)
+			jobs = append(jobs, g)
+		}
+		prj.AbstractGoal("all").ImpliedBy(jobs...)
+	})).BeNil(t)
+	build := NewBuilder(
+		gomkore.NewTrace(context.Background(), TestTracer{t}),
+		nil,
+	)
+	build.MaxJobs = 2
+	testerr.Shall(build.Project(prj)).BeNil(t)
+	if n := cj.runs.Load(); n != 4 {
+		t.Errorf("ran %d jobs, want 4", n)
+	}
+	if n := cj.max.Load(); n != 2 {
+		t.Errorf("max %d concurrent jobs, want 2", n)
+	}
+}
+
+// slowDoneTracer delays GoalDone of goals with a name prefix.
+type slowDoneTracer struct {
+	TestTracer
+	prefix string
+}
+
+func (tr slowDoneTracer) GoalDone(t *gomkore.Trace, g *gomkore.Goal, dt time.Duration, err error) {
+	if strings.HasPrefix(g.Name(), tr.prefix) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	tr.TestTracer.GoalDone(t, g, dt, err)
+}
+
+func Test_buildParallel_failedPremise(t *testing.T) {
+	var runs atomic.Int32
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		bad, _ := prj.AbstractGoal("bad").By(OpFunc("bad", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			time.Sleep(20 * time.Millisecond)
+			return errors.New("broken")
+		}))
+		dep := OpFunc("dep", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			runs.Add(1)
+			return nil
+		})
+		midA, _ := prj.AbstractGoal("midA").By(dep, bad)
+		midB, _ := prj.AbstractGoal("midB").By(dep, bad)
+		a, _ := prj.AbstractGoal("a").By(dep, midA)
+		b, _ := prj.AbstractGoal("b").By(dep, midB)
+		prj.AbstractGoal("all").ImpliedBy(a, b)
+	})).BeNil(t)
+	build := NewBuilder(
+		gomkore.NewTrace(context.Background(), slowDoneTracer{TestTracer{t}, "mid"}),
+		nil,
+	)
+	build.MaxJobs = 4
+	var aerr *gomkore.ActionError
+	if err := build.Project(prj); !errors.As(err, &aerr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := runs.Load(); n != 0 {
+		t.Errorf("%d actions ran after their premise failed", n)
+	}
+}
+
+type countCopy struct {
+	mkfs.Copy
+	runs int
+}
+
+func (cc *countCopy) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env) error {
+	cc.runs++
+	return cc.Copy.Do(tr, a, env)
+}
+
+func Test_buildFingerprints(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	testerr.Shall(os.WriteFile(src, []byte("foo"), 0666)).BeNil(t)
+	op := new(countCopy)
+	prj := gomkore.NewProject(dir)
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		prj.Goal(mkfs.File("dst.txt")).By(op, prj.Goal(mkfs.File("src.txt")))
+	})).BeNil(t)
+	build := NewBuilder(
+		gomkore.NewTrace(context.Background(), TestTracer{t}),
+		nil,
+	)
+	build.Fingerprints = true
+	buildRuns := func(step string, want int) {
+		t.Helper()
+		op.runs = 0
+		testerr.Shall(build.Project(prj)).BeNil(t)
+		if op.runs != want {
+			t.Fatalf("%s: %d runs, want %d", step, op.runs, want)
+		}
+	}
+
+	buildRuns("initial", 1)
+	buildRuns("unchanged", 0)
+
+	future := time.Now().Add(time.Hour)
+	testerr.Shall(os.Chtimes(src, future, future)).BeNil(t)
+	buildRuns("touched", 0)
+
+	testerr.Shall(os.WriteFile(src, []byte("bar"), 0666)).BeNil(t)
+	buildRuns("changed", 1)
+}
+
+func Test_buildState(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	testerr.Shall(os.WriteFile(src, []byte("foo"), 0666)).BeNil(t)
+	op := new(countCopy)
+	newPrj := func() *gomkore.Project {
+		prj := gomkore.NewProject(dir)
+		prj.State = testerr.Shall1(gomkore.OpenState(prj, "")).BeNil(t)
+		testerr.Shall(Edit(prj, func(prj ProjectEd) {
+			prj.Goal(mkfs.File("dst.txt")).By(op, prj.Goal(mkfs.File("src.txt")))
+		})).BeNil(t)
+		return prj
+	}
+	build := NewBuilder(
+		gomkore.NewTrace(context.Background(), TestTracer{t}),
+		nil,
+	)
+	build.Fingerprints = true
+
+	testerr.Shall(build.Project(newPrj())).BeNil(t)
+	if op.runs != 1 {
+		t.Fatalf("initial build: %d runs", op.runs)
+	}
+
+	future := time.Now().Add(time.Hour)
+	testerr.Shall(os.Chtimes(src, future, future)).BeNil(t)
+	prj := newPrj()
+	testerr.Shall(build.Project(prj)).BeNil(t)
+	if op.runs != 1 {
+		t.Fatalf("touched build: %d runs", op.runs)
+	}
+	as, ok := prj.State.Action(prj.Actions()[0])
+	if !ok {
+		t.Fatal("no action state")
+	}
+	if as.LastRun.IsZero() || len(as.Hash) == 0 || len(as.Results["dst.txt"]) == 0 {
+		t.Errorf("incomplete action state: %+v", as)
+	}
+}
+
+func TestBuilder_Plan(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	testerr.Shall(os.WriteFile(src, []byte("foo"), 0666)).BeNil(t)
+	op := new(countCopy)
+	prj := gomkore.NewProject(dir)
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		mid, _ := prj.Goal(mkfs.File("mid.txt")).By(op, prj.Goal(mkfs.File("src.txt")))
+		mid.AddLabels("mid")
+		prj.Goal(mkfs.File("dst.txt")).By(op, mid)
+	})).BeNil(t)
+	build := NewBuilder(
+		gomkore.NewTrace(context.Background(), TestTracer{t}),
+		nil,
+	)
+	checkPlan := func(step string, want ...gomkore.ScheduleReason) {
+		t.Helper()
+		plan := testerr.Shall1(build.Plan(prj)).BeNil(t)
+		if op.runs != 0 {
+			t.Fatalf("%s: plan ran %d operations", step, op.runs)
+		}
+		if len(plan.Steps) != len(want) {
+			t.Fatalf("%s: %d steps in plan %v, want %d", step, len(plan.Steps), plan.Steps, len(want))
+		}
+		for i, s := range plan.Steps {
+			if s.Reason != want[i] {
+				t.Errorf("%s: step %d: %s", step, i, s)
+			}
+		}
+	}
+
+	checkPlan("initial", gomkore.SchedResTimeZero, gomkore.SchedResTimeZero)
+	testerr.Shall(build.Project(prj)).BeNil(t)
+	op.runs = 0
+	checkPlan("built")
+
+	future := time.Now().Add(time.Hour)
+	testerr.Shall(os.Chtimes(src, future, future)).BeNil(t)
+	checkPlan("touched", gomkore.SchedOutdated, gomkore.SchedPrePlanned)
+
+	if plan := testerr.Shall1(build.PlanNamedGoals(prj, "mid.txt")).BeNil(t); len(plan.Steps) != 1 {
+		t.Errorf("unexpected plan of named goal: %v", plan.Steps)
+	}
+	if plan := testerr.Shall1(build.PlanLabeledGoals(prj, "mid")).BeNil(t); len(plan.Steps) != 1 {
+		t.Errorf("unexpected plan of labeled goal: %v", plan.Steps)
+	}
+}
+
+func TestBuilder_KeepGoing(t *testing.T) {
+	var runs []string
+	var mu sync.Mutex
+	op := func(name string, err error) gomkore.Operation {
+		return OpFunc(name, func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			mu.Lock()
+			runs = append(runs, name)
+			mu.Unlock()
+			return err
+		})
+	}
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		bad, _ := prj.AbstractGoal("bad").By(op("bad", errors.New("broken")))
+		dep, _ := prj.AbstractGoal("dep").By(op("dep", nil), bad)
+		good, _ := prj.AbstractGoal("good").By(op("good", nil))
+		prj.AbstractGoal("all").ImpliedBy(dep, good)
+	})).BeNil(t)
+	build := NewBuilder(
+		gomkore.NewTrace(context.Background(), TestTracer{t}),
+		nil,
+	)
+	build.KeepGoing = true
+	err := build.Project(prj)
+	var aerr *gomkore.ActionError
+	if !errors.As(err, &aerr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if aerr.Path != "all > dep > bad" {
+		t.Errorf("unexpected error path '%s'", aerr.Path)
+	}
+	slices.Sort(runs)
+	if !slices.Equal(runs, []string{"bad", "good"}) {
+		t.Errorf("unexpected runs: %v", runs)
+	}
+}
+
+func TestBuilder_subProject(t *testing.T) {
+	var runs []string
+	op := func(name string) gomkore.Operation {
+		return OpFunc(name, func(_ *gomkore.Trace, a *gomkore.Action, _ *gomkore.Env) error {
+			runs = append(runs, a.Project().String()+":"+name)
+			return nil
+		})
+	}
+	dir := t.TempDir()
+	sub := gomkore.NewProject("sub")
+	var lib GoalEd
+	testerr.Shall(Edit(sub, func(sub ProjectEd) {
+		lib, _ = sub.AbstractGoal("lib").By(op("lib"))
+		sub.AbstractGoal("tool").By(op("tool"), lib)
+	})).BeNil(t)
+	prj := gomkore.NewProject(dir)
+	var app GoalEd
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		prj.SubProject(sub)
+		app, _ = prj.AbstractGoal("app").By(op("app"), prj.SubGoal(lib))
+	})).BeNil(t)
+	testerr.Shall(prj.Validate()).BeNil(t)
+
+	if p := testerr.Shall1(sub.AbsPath("x")).BeNil(t); p != filepath.Join(dir, "sub", "x") {
+		t.Errorf("unexpected sub-project path '%s'", p)
+	}
+	if n := testerr.Shall1(prj.SubGoal(lib.Goal())).BeNil(t).Name(); n != "sub/lib" {
+		t.Errorf("unexpected sub-goal name '%s'", n)
+	}
+
+	subAct := testerr.Shall1(prj.SubProject(sub)).BeNil(t).ResultOf()
+	if libAct := testerr.Shall1(prj.SubGoal(lib.Goal())).BeNil(t).ResultOf(); len(subAct) != 1 ||
+		!slices.Equal(libAct, subAct) {
+		t.Errorf("sub-project and sub-goal not built by one action: %v / %v", subAct, libAct)
+	}
+
+	build := NewBuilder(
+		gomkore.NewTrace(context.Background(), TestTracer{t}),
+		nil,
+	)
+	testerr.Shall(build.Goals(app.Goal())).BeNil(t)
+	if !slices.Equal(runs, []string{"sub:lib", "sub:tool", filepath.Base(dir) + ":app"}) {
+		t.Errorf("unexpected runs: %v", runs)
+	}
+}
+
+func TestBuilder_subProject_jobs(t *testing.T) {
+	var cj concurrentJobs
+	op := cj.op()
+	sub := gomkore.NewProject("sub")
+	testerr.Shall(Edit(sub, func(sub ProjectEd) {
+		for i := range 4 {
+			sub.AbstractGoal(fmt.Sprintf("job%d", i)).By(op)
+		}
+	})).BeNil(t)
+	prj := gomkore.NewProject(t.TempDir())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		prj.SubProject(sub)
+	})).BeNil(t)
+
+	build := NewBuilder(
+		gomkore.NewTrace(context.Background(), TestTracer{t}),
+		nil,
+	)
+	build.MaxJobs = 2
+	testerr.Shall(build.Project(prj)).BeNil(t)
+	if n := cj.max.Load(); n != 2 {
+		t.Errorf("max %d concurrent jobs, want 2", n)
+	}
+	var subActs int
+	for _, ar := range build.Report().Actions {
+		if ar.Project == "sub" {
+			subActs++
+		}
+	}
+	if subActs != 4 {
+		t.Errorf("%d sub-project actions in report, want 4", subActs)
+	}
+}
+
+func TestBuilder_subProject_keepGoing(t *testing.T) {
+	var good atomic.Bool
+	sub := gomkore.NewProject("sub")
+	testerr.Shall(Edit(sub, func(sub ProjectEd) {
+		sub.AbstractGoal("bad").By(OpFunc("bad", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			return errors.New("broken")
+		}))
+		sub.AbstractGoal("good").By(OpFunc("good", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			good.Store(true)
+			return nil
+		}))
+	})).BeNil(t)
+	prj := gomkore.NewProject(t.TempDir())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) { prj.SubProject(sub) })).BeNil(t)
+	build := NewBuilder(
+		gomkore.NewTrace(context.Background(), TestTracer{t}),
+		nil,
+	)
+	build.KeepGoing = true
+	if err := build.Project(prj); err == nil {
+		t.Fatal("build did not fail")
+	}
+	if !good.Load() {
+		t.Error("sub-build did not keep going")
+	}
+}
+
+func TestAction_retry(t *testing.T) {
+	var runs int
+	prj := gomkore.NewProject(t.Name())
+	var flaky ActionEd
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		_, flaky = prj.AbstractGoal("flaky").By(OpFunc("flaky",
+			func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+				if runs++; runs < 3 {
+					return errors.New("flake")
+				}
+				return nil
+			},
+		))
+	})).BeNil(t)
+	flaky.SetRetry(&gomkore.RetryPolicy{Count: 2, Backoff: time.Millisecond})
+	build := NewBuilder(gomkore.NewTrace(context.Background(), TestTracer{t}), nil)
+	testerr.Shall(build.Project(prj)).BeNil(t)
+	if runs != 3 {
+		t.Errorf("unexpected number of runs: %d", runs)
+	}
+
+	runs = 0
+	flaky.SetRetry(&gomkore.RetryPolicy{Count: 5, If: func(error) bool { return false }})
+	testerr.Shall(build.Project(prj)).Check(t, testerr.Msg("action (flaky) for flaky failed: flake"))
+	if runs != 1 {
+		t.Errorf("unexpected number of runs: %d", runs)
+	}
+}
+
+func TestAction_timeout(t *testing.T) {
+	var runs int
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		_, hang := prj.AbstractGoal("hang").By(OpFunc("hang",
+			func(tr *gomkore.Trace, _ *gomkore.Action, _ *gomkore.Env) error {
+				runs++
+				<-tr.Ctx().Done()
+				return tr.Ctx().Err()
+			},
+		))
+		hang.SetTimeout(10 * time.Millisecond)
+		hang.SetRetry(&gomkore.RetryPolicy{
+			Count: 1,
+			If:    func(err error) bool { return errors.Is(err, gomkore.ErrActionTimeout) },
+		})
+	})).BeNil(t)
+	build := NewBuilder(gomkore.NewTrace(context.Background(), TestTracer{t}), nil)
+	err := build.Project(prj)
+	if !errors.Is(err, gomkore.ErrActionTimeout) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runs != 2 {
+		t.Errorf("unexpected number of runs: %d", runs)
+	}
+}
+
+func TestBuilder_interrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var runs int
+	op := OpFunc("cancel", func(tr *gomkore.Trace, a *gomkore.Action, _ *gomkore.Env) error {
+		runs++
+		path := testerr.Shall1(a.Project().AbsPath(a.Result(0).Name())).BeNil(t)
+		testerr.Shall(os.WriteFile(path, []byte("partial"), 0666)).BeNil(t)
+		cancel()
+		return tr.Ctx().Err()
+	})
+	prj := gomkore.NewProject(t.TempDir())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		for _, n := range []string{"a", "b"} {
+			g, _ := prj.Goal(mkfs.File(n)).By(op)
+			g.SetRemovable(true)
+		}
+	})).BeNil(t)
+	build := NewBuilder(gomkore.NewTrace(ctx, TestTracer{t}), nil)
+	build.KeepGoing = true
+	err := build.Project(prj)
+	var ierr *gomkore.InterruptedError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected cause: %v", ierr.Cause)
+	}
+	if runs != 1 || len(ierr.Actions) != 1 {
+		t.Errorf("runs: %d, interrupted: %v", runs, ierr.Actions)
+	}
+	for _, n := range []string{"a", "b"} {
+		if _, err := os.Stat(filepath.Join(prj.Dir, n)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("result %s not removed: %v", n, err)
+		}
+	}
+}
+
+func TestBuilder_interrupt_clock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	prj := gomktest.TempProject(t, gomktest.File{Path: "keep", MTime: gomktest.Time(1)})
+	prj.Clock = gomktest.NewClock(gomktest.Epoch)
+	op := OpFunc("cancel", func(tr *gomkore.Trace, a *gomkore.Action, _ *gomkore.Env) error {
+		gomktest.WriteFile(t, a.Project(), gomktest.File{Path: "part", MTime: gomktest.Time(2)})
+		cancel()
+		return tr.Ctx().Err()
+	})
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		keep, part := prj.Goal(mkfs.File("keep")), prj.Goal(mkfs.File("part"))
+		keep.SetRemovable(true)
+		part.SetRemovable(true)
+		prj.NewAction(nil, []GoalEd{keep, part}, op)
+	})).BeNil(t)
+	build := NewBuilder(gomkore.NewTrace(ctx, TestTracer{t}), nil)
+	var ierr *gomkore.InterruptedError
+	if err := build.Project(prj); !errors.As(err, &ierr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(prj.Dir, "keep")); err != nil {
+		t.Errorf("unchanged result removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(prj.Dir, "part")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("partial result not removed: %v", err)
+	}
+}
+
+func TestBuilder_Report(t *testing.T) {
+	op := func(name string, err error) gomkore.Operation {
+		return OpFunc(name, func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			return err
+		})
+	}
+	prj := gomktest.TempProject(t,
+		gomktest.File{Path: "src.txt", MTime: gomktest.Time(1)},
+		gomktest.File{Path: "up.txt", MTime: gomktest.Time(2)},
+	)
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		up, _ := prj.Goal(mkfs.File("up.txt")).By(op("up", nil), prj.Goal(mkfs.File("src.txt")))
+		bad, _ := prj.AbstractGoal("bad").By(op("bad", errors.New("broken")))
+		dep, _ := prj.AbstractGoal("dep").By(op("dep", nil), bad)
+		good, _ := prj.AbstractGoal("good").By(op("good", nil))
+		ign, a := prj.AbstractGoal("ign").By(op("ign", errors.New("ignored")))
+		a.SetIgnoreError(true)
+		prj.AbstractGoal("all").ImpliedBy(dep, good, ign, up)
+	})).BeNil(t)
+	build := NewBuilder(gomkore.NewTrace(context.Background(), TestTracer{t}), nil)
+	build.KeepGoing = true
+	if err := build.Project(prj); err == nil {
+		t.Fatal("build did not fail")
+	}
+
+	var buf strings.Builder
+	testerr.Shall(build.Report().WriteJSON(&buf)).BeNil(t)
+	var rep gomkore.BuildReport
+	testerr.Shall(json.Unmarshal([]byte(buf.String()), &rep)).BeNil(t)
+	if rep.Error == "" || rep.End.Before(rep.Start) {
+		t.Errorf("unexpected report: error '%s', start %s, end %s", rep.Error, rep.Start, rep.End)
+	}
+	goals := make(map[string]gomkore.GoalReport)
+	for _, g := range rep.Goals {
+		goals[g.Name] = g
+	}
+	for n, st := range map[string]gomkore.BuildStatus{
+		"bad":  gomkore.StatusFailed,
+		"dep":  gomkore.StatusSkipped,
+		"good": gomkore.StatusRebuilt,
+		"ign":  gomkore.StatusRebuilt,
+		"all":  gomkore.StatusSkipped,
+	} {
+		if s := goals[n].Status; s != st {
+			t.Errorf("goal %s: status %s, want %s", n, s, st)
+		}
+	}
+	if r := goals["dep"].Reason; r != "failed premise bad" {
+		t.Errorf("unexpected skip reason '%s'", r)
+	}
+	actions := make(map[string]gomkore.ActionReport)
+	for _, a := range rep.Actions {
+		actions[a.Action] = a
+	}
+	if len(actions) != 5 {
+		t.Errorf("unexpected actions: %v", rep.Actions)
+	}
+	if a := actions["up"]; a.Status != gomkore.StatusUpToDate || a.Reason != "" {
+		t.Errorf("action up: status %s, reason '%s'", a.Status, a.Reason)
+	}
+	if a := actions["dep"]; a.Status != gomkore.StatusSkipped || a.Reason != "failed premise bad" {
+		t.Errorf("action dep: status %s, reason '%s'", a.Status, a.Reason)
+	}
+	for n, st := range map[string]gomkore.BuildStatus{
+		"bad":  gomkore.StatusFailed,
+		"good": gomkore.StatusRebuilt,
+		"ign":  gomkore.StatusIgnoredError,
+	} {
+		a := actions[n]
+		if a.Status != st {
+			t.Errorf("action %s: status %s, want %s", n, a.Status, st)
+		}
+		if a.Reason != "result missing" {
+			t.Errorf("action %s: reason '%s'", n, a.Reason)
+		}
+	}
+	if e := actions["bad"].Error; e != "broken" {
+		t.Errorf("unexpected action error '%s'", e)
+	}
+}
+
+type doneTracer struct {
+	TestTracer
+	done []string
+}
+
+func (tr *doneTracer) ActionDone(t *gomkore.Trace, a *gomkore.Action, dt time.Duration, err error) {
+	tr.TestTracer.ActionDone(t, a, dt, err)
+	if _, ok := t.Top().(*gomkore.Action); !ok {
+		tr.t.Errorf("action %s done without action on trace %s", a, t)
+	}
+	tr.done = append(tr.done, fmt.Sprintf("(%t) %v", a.Op != nil, err))
+}
+
+func (tr *doneTracer) GoalDone(t *gomkore.Trace, g *gomkore.Goal, dt time.Duration, err error) {
+	tr.TestTracer.GoalDone(t, g, dt, err)
+	tr.done = append(tr.done, fmt.Sprintf("[%s] %v", g.Name(), err != nil))
+}
+
+func TestBuilder_doneEvents(t *testing.T) {
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		x, _ := prj.AbstractGoal("x").By(OpFunc("x", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			return nil
+		}))
+		prj.AbstractGoal("all").ImpliedBy(x)
+	})).BeNil(t)
+	tr := &doneTracer{TestTracer: TestTracer{t}}
+	build := NewBuilder(gomkore.NewTrace(context.Background(), tr), nil)
+	testerr.Shall(build.Project(prj)).BeNil(t)
+	if !slices.Equal(tr.done, []string{"(true) <nil>", "[x] false", "(false) <nil>", "[all] false"}) {
+		t.Errorf("unexpected done events: %v", tr.done)
+	}
+}
@@ -0,0 +1,211 @@
+package gomk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// LogFileTracer writes the complete output of each action to a log file in
+// Dir. The name of the file is made from the build ID, the trace ID and the
+// description of the action. Output to Out and Err of the action is still
+// passed to the writers of the action's environment. Use LogFileTracer with a
+// [MultiTracer] to combine it with other tracers. Dir is not cleaned up by
+// LogFileTracer.
+type LogFileTracer struct {
+	Dir string
+
+	mu    sync.Mutex
+	files map[*gomkore.Trace]*logFile
+	logs  map[*gomkore.Action]string
+}
+
+var _ gomkore.Tracer = (*LogFileTracer)(nil)
+
+func NewLogFileTracer(dir string) *LogFileTracer {
+	return &LogFileTracer{
+		Dir:   dir,
+		files: make(map[*gomkore.Trace]*logFile),
+		logs:  make(map[*gomkore.Action]string),
+	}
+}
+
+// LogFile returns the name of the log file of a's last run, if any.
+func (tr *LogFileTracer) LogFile(a *gomkore.Action) string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.logs[a]
+}
+
+// WriteFailures writes a summary of all failed actions in err to w, one line
+// per action with its error followed by a line with its log file.
+func (tr *LogFileTracer) WriteFailures(w io.Writer, err error) error {
+	for _, aerr := range actionErrors(err, nil) {
+		_, err := fmt.Fprintf(w, "FAILED (%s) for %s: %s\n", aerr.Action, aerr.Path, aerr.Err)
+		if err != nil {
+			return err
+		}
+		if log := tr.LogFile(aerr.Action); log != "" {
+			if _, err = fmt.Fprintf(w, "\tlog: %s\n", log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func actionErrors(err error, res []*gomkore.ActionError) []*gomkore.ActionError {
+	switch e := err.(type) {
+	case nil:
+		return res
+	case *gomkore.ActionError:
+		return append(res, e)
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			res = actionErrors(err, res)
+		}
+		return res
+	}
+	return actionErrors(errors.Unwrap(err), res)
+}
+
+func (tr *LogFileTracer) Debug(t *gomkore.Trace, msg string, args ...any) {}
+func (tr *LogFileTracer) Info(t *gomkore.Trace, msg string, args ...any)  {}
+func (tr *LogFileTracer) Warn(t *gomkore.Trace, msg string, args ...any)  {}
+
+func (tr *LogFileTracer) StartProject(t *gomkore.Trace, p *gomkore.Project, activity string) {
+}
+
+func (tr *LogFileTracer) DoneProject(t *gomkore.Trace, p *gomkore.Project, activity string, dt time.Duration) {
+}
+
+func (tr *LogFileTracer) SetupActionEnv(t *gomkore.Trace, env *gomkore.Env) (*gomkore.Env, error) {
+	a, ok := t.Top().(*gomkore.Action)
+	if !ok {
+		return env, nil
+	}
+	if err := os.MkdirAll(tr.Dir, 0777); err != nil {
+		return nil, err
+	}
+	name := filepath.Join(tr.Dir, fmt.Sprintf("%d-%d-%s.log",
+		t.Build(),
+		t.TopID(),
+		logFileSlug(a.String()),
+	))
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	log := &logFile{f: f}
+	e := env.Sub()
+	e.Out = &logTee{log: log, w: env.Out}
+	e.Err = &logTee{log: log, w: env.Err}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.files[t] = log
+	tr.logs[a] = name
+	return e, nil
+}
+
+func (tr *LogFileTracer) CloseActionEnv(t *gomkore.Trace, env *gomkore.Env) error {
+	tr.mu.Lock()
+	log := tr.files[t]
+	delete(tr.files, t)
+	tr.mu.Unlock()
+	if log == nil {
+		return nil
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	return log.f.Close()
+}
+
+func (tr *LogFileTracer) RunAction(t *gomkore.Trace, a *gomkore.Action)         {}
+func (tr *LogFileTracer) RunImplicitAction(t *gomkore.Trace, a *gomkore.Action) {}
+
+func (tr *LogFileTracer) ActionDone(t *gomkore.Trace, a *gomkore.Action, dt time.Duration, err error) {
+}
+
+func (tr *LogFileTracer) ScheduleResTimeZero(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+}
+
+func (tr *LogFileTracer) ScheduleNotPremises(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+}
+
+func (tr *LogFileTracer) SchedulePreTimeZero(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+}
+
+func (tr *LogFileTracer) ScheduleOutdated(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+}
+
+func (tr *LogFileTracer) ScheduleHashChanged(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+}
+
+func (tr *LogFileTracer) CheckGoal(t *gomkore.Trace, g *gomkore.Goal)               {}
+func (tr *LogFileTracer) GoalUpToDate(t *gomkore.Trace, g *gomkore.Goal)            {}
+func (tr *LogFileTracer) GoalNeedsActions(t *gomkore.Trace, g *gomkore.Goal, n int) {}
+func (tr *LogFileTracer) RemoveArtefact(t *gomkore.Trace, g *gomkore.Goal)          {}
+
+func (tr *LogFileTracer) GoalDone(t *gomkore.Trace, g *gomkore.Goal, dt time.Duration, err error) {
+}
+
+// logFileSlug makes a file name component from an action's description.
+func logFileSlug(s string) string {
+	const maxLen = 64
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	return s
+}
+
+// logFile is shared by the Out and Err writers of an action that may be
+// written concurrently.
+type logFile struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// logTee writes to the log file and to w, if w is not nil.
+type logTee struct {
+	log *logFile
+	w   io.Writer
+}
+
+var _ gomkore.OutputCapture = (*logTee)(nil)
+
+func (lt *logTee) Write(p []byte) (int, error) {
+	lt.log.mu.Lock()
+	_, err := lt.log.f.Write(p)
+	lt.log.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	if lt.w == nil {
+		return len(p), nil
+	}
+	return lt.w.Write(p)
+}
+
+// CapturedOutput passes on the output captured by w, if any.
+func (lt *logTee) CapturedOutput() []byte {
+	if c, ok := lt.w.(gomkore.OutputCapture); ok {
+		return c.CapturedOutput()
+	}
+	return nil
+}
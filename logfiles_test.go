@@ -0,0 +1,66 @@
+package gomk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/testerr"
+)
+
+func TestLogFileTracer(t *testing.T) {
+	var fail *gomkore.Action
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		prj.AbstractGoal("ok").By(OpFunc("say hello", func(_ *gomkore.Trace, _ *gomkore.Action, env *gomkore.Env) error {
+			_, err := fmt.Fprintln(env.Out, "hello")
+			return err
+		}))
+		_, act := prj.AbstractGoal("fail").By(OpFunc("complain", func(_ *gomkore.Trace, _ *gomkore.Action, env *gomkore.Env) error {
+			fmt.Fprintln(env.Out, "trying")
+			fmt.Fprintln(env.Err, "cannot")
+			return errors.New("broken")
+		}))
+		fail = act.Action()
+	})).BeNil(t)
+
+	dir := filepath.Join(t.TempDir(), "logs")
+	logs := NewLogFileTracer(dir)
+	var out bytes.Buffer
+	env := gomkore.DefaultEnv(nil)
+	env.Out, env.Err = &out, &out
+	build := NewBuilder(gomkore.NewTrace(context.Background(), NewMultiTracer(TestTracer{t}, logs)), env)
+	build.KeepGoing = true
+	err := build.Project(prj)
+	if err == nil {
+		t.Fatal("build did not fail")
+	}
+
+	if s := out.String(); !strings.Contains(s, "trying\n") || !strings.Contains(s, "cannot\n") {
+		t.Errorf("output not passed on: '%s'", s)
+	}
+	log := logs.LogFile(fail)
+	if base := filepath.Base(log); !strings.HasPrefix(base, fmt.Sprintf("%d-", fail.LastBuild())) ||
+		!strings.HasSuffix(base, "-complain.log") {
+		t.Errorf("unexpected log file name %s", base)
+	}
+	data := testerr.Shall1(os.ReadFile(log)).BeNil(t)
+	if s := string(data); s != "trying\ncannot\n" {
+		t.Errorf("unexpected log content '%s'", s)
+	}
+	if entries := testerr.Shall1(os.ReadDir(dir)).BeNil(t); len(entries) != 2 {
+		t.Errorf("%d log files", len(entries))
+	}
+
+	var sum strings.Builder
+	testerr.Shall(logs.WriteFailures(&sum, err)).BeNil(t)
+	if s := sum.String(); s != "FAILED (complain) for fail: broken\n\tlog: "+log+"\n" {
+		t.Errorf("unexpected failure summary:\n%s", s)
+	}
+}
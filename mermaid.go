@@ -0,0 +1,97 @@
+package gomk
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// WriteMermaid writes the diagram of prj as Mermaid flowchart to w. It shows
+// the same as [Diagrammer.WriteDot]: Abstract goals are dashed, tangible goals
+// show their artefact type and update mode, edges to results of ordered
+// update modes are numbered and implicit actions are drawn as dashed edges.
+func (dia *Diagrammer) WriteMermaid(w io.Writer, prj *gomkore.Project) (err error) {
+	defer recoverDiagram(&err)
+	g, err := dia.graph(prj)
+	if err != nil {
+		return err
+	}
+	writeMermaid(w, g, dia.RankDir)
+	return nil
+}
+
+func writeMermaid(w io.Writer, g *diaGraph, rankDir string) {
+	if rankDir == "" {
+		rankDir = "TB"
+	}
+	fmt.Fprintf(w, "flowchart %s\n", rankDir)
+	fmt.Fprintln(w, "\tclassDef abstract stroke-dasharray:5 5")
+	fmt.Fprintln(w, "\tclassDef abstractBold stroke-dasharray:5 5,stroke-width:3px")
+	fmt.Fprintln(w, "\tclassDef bold stroke-width:3px")
+	for _, n := range g.nodes {
+		switch n.kind {
+		case diaAbstract:
+			class := "abstract"
+			if n.bold {
+				class = "abstractBold"
+			}
+			fmt.Fprintf(w, "\t%s[\"%s\"]:::%s\n", n.id, escMermaid(n.label), class)
+		case diaTangible:
+			fmt.Fprintf(w, "\t%s[\"%s%s<br/>%s\"]%s\n",
+				n.id,
+				n.atfType,
+				n.updMode,
+				escMermaid(n.label),
+				mermaidBold(n),
+			)
+		case diaAction:
+			fmt.Fprintf(w, "\t%s(\"%s\")%s\n", n.id, escMermaid(n.label), mermaidBold(n))
+		case diaJoint:
+			fmt.Fprintf(w, "\t%s((\" \"))\n", n.id)
+		}
+	}
+	for _, e := range g.edges {
+		var arrow string
+		switch {
+		case e.noHead:
+			arrow = "-.-"
+		case e.implicit:
+			arrow = "-.->"
+		default:
+			arrow = "-->"
+		}
+		if e.order > 0 {
+			fmt.Fprintf(w, "\t%s %s|\"%d\"| %s\n", e.from, arrow, e.order, e.to)
+		} else {
+			fmt.Fprintf(w, "\t%s %s %s\n", e.from, arrow, e.to)
+		}
+	}
+	for _, n := range g.nodes {
+		if c := n.state.color(); c != "" {
+			fmt.Fprintf(w, "\tstyle %s fill:%s\n", n.id, c)
+		}
+	}
+	for i, e := range g.edges {
+		if e.trigger {
+			fmt.Fprintf(w, "\tlinkStyle %d stroke:%s,stroke-width:3px\n", i, diaTriggerColor)
+		}
+	}
+}
+
+func mermaidBold(n *diaNode) string {
+	if n.bold {
+		return ":::bold"
+	}
+	return ""
+}
+
+var mermaidEsc = strings.NewReplacer(
+	"#", "#35;",
+	"\"", "#quot;",
+	"<", "#lt;",
+	">", "#gt;",
+)
+
+func escMermaid(s string) string { return mermaidEsc.Replace(s) }
@@ -7,7 +7,6 @@ import (
 	"io"
 	"io/fs"
 	"log/slog"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -62,7 +61,8 @@ func (cp Copy) toFile(tr *gomkore.Trace, prj *gomkore.Project, dst File, srcs []
 	if err != nil {
 		return err
 	}
-	if err := cp.provideDir(filepath.Dir(dstPath)); err != nil {
+	fsys := prj.FileSystem()
+	if err := cp.provideDir(fsys, filepath.Dir(dstPath)); err != nil {
 		return err
 	}
 	if len(srcs) == 1 {
@@ -70,13 +70,13 @@ func (cp Copy) toFile(tr *gomkore.Trace, prj *gomkore.Project, dst File, srcs []
 		if err != nil {
 			return err
 		}
-		st, err := os.Stat(src)
+		st, err := fsys.Stat(src)
 		if err != nil {
 			return err
 		}
-		return cp.copyFile(tr, dstPath, src, st)
+		return cp.copyFile(tr, fsys, dstPath, src, st)
 	}
-	w, err := os.Create(dstPath)
+	w, err := fsys.Create(dstPath, 0666)
 	if err != nil {
 		return fmt.Errorf("FsCopy to %s: %w", dst.Path(), err)
 	}
@@ -96,7 +96,7 @@ func (cp Copy) toFile(tr *gomkore.Trace, prj *gomkore.Project, dst File, srcs []
 			slog.String(`src`, srcPath),
 			slog.String(`dst`, dstPath),
 		)
-		r, err := os.Open(srcPath)
+		r, err := fsys.Open(srcPath)
 		if err != nil {
 			return fmt.Errorf("FsCopy to %s: %w", dst.Path(), err)
 		}
@@ -117,6 +117,7 @@ func (cp Copy) toList(tr *gomkore.Trace, prj *gomkore.Project, dst DirList, srcs
 	if err != nil {
 		return err
 	}
+	fsys := prj.FileSystem()
 	for _, src := range srcs {
 		srcPath, err := prj.AbsPath(src.Path())
 		if err != nil {
@@ -130,18 +131,18 @@ func (cp Copy) toList(tr *gomkore.Trace, prj *gomkore.Project, dst DirList, srcs
 		}
 		switch src := src.(type) {
 		case File:
-			st, err := os.Stat(srcPath)
+			st, err := fsys.Stat(srcPath)
 			if err != nil {
 				return err
 			}
 			bnm := filepath.Base(src.Path())
-			err = cp.copyFile(tr, filepath.Join(dstPath, bnm), srcPath, st)
+			err = cp.copyFile(tr, fsys, filepath.Join(dstPath, bnm), srcPath, st)
 			if err != nil {
 				return err
 			}
 		case DirList:
-			err = src.ls(srcPath, func(_ string, e fs.DirEntry) error {
-				return cp.copyEntry(tr,
+			err = src.ls(fsys, srcPath, func(_ string, e fs.DirEntry) error {
+				return cp.copyEntry(tr, fsys,
 					filepath.Join(dstPath, e.Name()),
 					filepath.Join(srcPath, e.Name()),
 				)
@@ -150,8 +151,8 @@ func (cp Copy) toList(tr *gomkore.Trace, prj *gomkore.Project, dst DirList, srcs
 				return err
 			}
 		case DirTree:
-			err = src.ls(srcPath, func(s string, e fs.DirEntry) error {
-				return cp.copyEntry(tr,
+			err = src.ls(fsys, srcPath, func(s string, e fs.DirEntry) error {
+				return cp.copyEntry(tr, fsys,
 					filepath.Join(dstPath, e.Name()),
 					s,
 				)
@@ -170,6 +171,7 @@ func (cp Copy) toTree(tr *gomkore.Trace, prj *gomkore.Project, dst DirTree, srcs
 	if err != nil {
 		return err
 	}
+	fsys := prj.FileSystem()
 	for _, src := range srcs {
 		srcPath, err := copyCheckNesting(prj, dst, dstPath, src)
 		if err != nil {
@@ -178,18 +180,18 @@ func (cp Copy) toTree(tr *gomkore.Trace, prj *gomkore.Project, dst DirTree, srcs
 		}
 		switch src := src.(type) {
 		case File:
-			st, err := os.Stat(srcPath)
+			st, err := fsys.Stat(srcPath)
 			if err != nil {
 				return err
 			}
 			bnm := filepath.Base(src.Path())
-			err = cp.copyFile(tr, filepath.Join(dstPath, bnm), srcPath, st)
+			err = cp.copyFile(tr, fsys, filepath.Join(dstPath, bnm), srcPath, st)
 			if err != nil {
 				return err
 			}
 		case DirList:
-			err = src.ls(srcPath, func(_ string, e fs.DirEntry) error {
-				return cp.copyEntry(tr,
+			err = src.ls(fsys, srcPath, func(_ string, e fs.DirEntry) error {
+				return cp.copyEntry(tr, fsys,
 					filepath.Join(dstPath, e.Name()),
 					filepath.Join(srcPath, e.Name()),
 				)
@@ -198,8 +200,8 @@ func (cp Copy) toTree(tr *gomkore.Trace, prj *gomkore.Project, dst DirTree, srcs
 				return err
 			}
 		case DirTree:
-			err = src.ls(srcPath, func(s string, _ fs.DirEntry) error {
-				return cp.copyEntry(tr,
+			err = src.ls(fsys, srcPath, func(s string, _ fs.DirEntry) error {
+				return cp.copyEntry(tr, fsys,
 					filepath.Join(dstPath, s),
 					filepath.Join(srcPath, s),
 				)
@@ -244,23 +246,23 @@ func copyCheckNesting(prj *gomkore.Project, dst Directory, dstPath string, src A
 	)
 }
 
-func (cp Copy) copyEntry(tr *gomkore.Trace, dst, src string) error {
-	sstat, err := os.Stat(src)
+func (cp Copy) copyEntry(tr *gomkore.Trace, fsys gomkore.FileSystem, dst, src string) error {
+	sstat, err := fsys.Stat(src)
 	if err != nil {
 		return err
 	}
 	if !sstat.IsDir() {
-		return cp.copyFile(tr, dst, src, sstat)
+		return cp.copyFile(tr, fsys, dst, src, sstat)
 	}
 	tr.Debug("FS copy: mkdir `src` -> `dst`",
 		slog.String(`src`, src),
 		slog.String(`dst`, dst),
 	)
-	return os.MkdirAll(dst, sstat.Mode().Perm())
+	return fsys.MkdirAll(dst, sstat.Mode().Perm())
 
 }
 
-func (cp Copy) copyFile(tr *gomkore.Trace, dst, src string, sstat fs.FileInfo) error {
+func (cp Copy) copyFile(tr *gomkore.Trace, fsys gomkore.FileSystem, dst, src string, sstat fs.FileInfo) error {
 	if src == dst {
 		return nil
 	}
@@ -268,18 +270,15 @@ func (cp Copy) copyFile(tr *gomkore.Trace, dst, src string, sstat fs.FileInfo) e
 		slog.String(`src`, src),
 		slog.String(`dst`, dst),
 	)
-	if err := cp.provideDir(filepath.Dir(dst)); err != nil {
+	if err := cp.provideDir(fsys, filepath.Dir(dst)); err != nil {
 		return err
 	}
-	w, err := os.OpenFile(dst,
-		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
-		sstat.Mode().Perm(),
-	)
+	w, err := fsys.Create(dst, sstat.Mode().Perm())
 	if err != nil {
 		return err
 	}
 	defer w.Close()
-	r, err := os.Open(src)
+	r, err := fsys.Open(src)
 	if err != nil {
 		return err
 	}
@@ -290,11 +289,11 @@ func (cp Copy) copyFile(tr *gomkore.Trace, dst, src string, sstat fs.FileInfo) e
 	return err
 }
 
-func (cp Copy) provideDir(path string) error {
+func (cp Copy) provideDir(fsys gomkore.FileSystem, path string) error {
 	if cp.MkDirMode == 0 {
 		return nil
 	}
-	return os.MkdirAll(path, cp.MkDirMode)
+	return fsys.MkdirAll(path, cp.MkDirMode)
 }
 
 func (cp Copy) WriteHash(h hash.Hash, a *gomkore.Action, _ *gomkore.Env) (bool, error) {
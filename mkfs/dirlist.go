@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -18,7 +19,10 @@ type DirList struct {
 	Filter Filter
 }
 
-var _ Directory = DirList{}
+var (
+	_ Directory                = DirList{}
+	_ gomkore.HashableArtefact = DirList{}
+)
 
 func (d DirList) Path() string { return d.Dir }
 
@@ -27,7 +31,7 @@ func (d DirList) List(in *gomkore.Project) (ls []string, err error) {
 	if err != nil {
 		return nil, err
 	}
-	err = d.ls(prjDir, func(_ string, e fs.DirEntry) error {
+	err = d.ls(in.FileSystem(), prjDir, func(_ string, e fs.DirEntry) error {
 		ls = append(ls, filepath.Join(d.Dir, e.Name()))
 		return nil
 	})
@@ -44,7 +48,7 @@ func (d DirList) Contains(in *gomkore.Project, a Artefact) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	stat, err := os.Stat(aPrj)
+	stat, err := in.FileSystem().Stat(aPrj)
 	if err != nil {
 		return false, err
 	}
@@ -60,7 +64,7 @@ func (d DirList) Goals(in *gomkore.Project) (gs []*gomkore.Goal, err error) {
 	if err != nil {
 		return nil, err
 	}
-	err = d.ls(prjDir, func(_ string, e fs.DirEntry) error {
+	err = d.ls(in.FileSystem(), prjDir, func(_ string, e fs.DirEntry) error {
 		p := filepath.Join(d.Dir, e.Name())
 		if e.IsDir() {
 			dir := DirList{Dir: p, Filter: d.Filter}
@@ -105,7 +109,7 @@ func (d DirList) StateAt(in *gomkore.Project) (t time.Time, err error) {
 	if err != nil {
 		return time.Time{}, err
 	}
-	err = d.ls(prjDir, func(_ string, e fs.DirEntry) error {
+	err = d.ls(in.FileSystem(), prjDir, func(_ string, e fs.DirEntry) error {
 		if info, err := e.Info(); err != nil {
 			return err
 		} else if mt := info.ModTime(); mt.After(t) {
@@ -119,12 +123,22 @@ func (d DirList) StateAt(in *gomkore.Project) (t time.Time, err error) {
 	return t, nil
 }
 
+// WriteHash writes d's filter and the paths and contents of all entries in d
+// to h.
+func (d DirList) WriteHash(h hash.Hash, in *gomkore.Project) (bool, error) {
+	root, err := in.AbsPath(d.Path())
+	if err != nil {
+		return false, err
+	}
+	return hashDir(h, in.FileSystem(), root, d.Filter, d.ls)
+}
+
 func (d DirList) Exists(in *gomkore.Project) (bool, error) {
 	ap, err := in.AbsPath(d.Path())
 	if err != nil {
 		return false, err
 	}
-	st, err := os.Stat(ap)
+	st, err := in.FileSystem().Stat(ap)
 	switch {
 	case err == nil:
 		if !st.IsDir() {
@@ -142,14 +156,15 @@ func (d DirList) Remove(in *gomkore.Project) error {
 	if err != nil {
 		return err
 	}
-	err = d.ls(prjDir, func(_ string, e fs.DirEntry) error {
+	fsys := in.FileSystem()
+	err = d.ls(fsys, prjDir, func(_ string, e fs.DirEntry) error {
 		p := filepath.Join(prjDir, e.Name())
-		return os.Remove(p)
+		return fsys.Remove(p)
 	})
 	if err != nil {
 		return err
 	}
-	return rmDirIfEmpty(prjDir)
+	return rmDirIfEmpty(fsys, prjDir)
 }
 
 func (d DirList) Moved(strip, dest Directory) (DirList, error) {
@@ -173,8 +188,8 @@ func (d DirList) Moved(strip, dest Directory) (DirList, error) {
 	}, nil
 }
 
-func (d DirList) ls(prjDir string, do func(p string, e fs.DirEntry) error) error {
-	rdir, err := os.ReadDir(prjDir)
+func (d DirList) ls(fsys gomkore.FileSystem, prjDir string, do func(p string, e fs.DirEntry) error) error {
+	rdir, err := fsys.ReadDir(prjDir)
 	if err != nil {
 		return err
 	}
@@ -12,7 +12,7 @@ import (
 
 func TestDirList_ls(t *testing.T) {
 	d := DirList{Dir: "ls", Filter: IsDir(false)}
-	d.ls("testdata/ls", func(p string, e fs.DirEntry) error {
+	d.ls(gomkore.OSFileSystem{}, "testdata/ls", func(p string, e fs.DirEntry) error {
 		if e.IsDir() {
 			return nil
 		}
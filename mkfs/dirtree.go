@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -19,7 +20,10 @@ type DirTree struct {
 	Filter Filter
 }
 
-var _ Directory = DirTree{}
+var (
+	_ Directory                = DirTree{}
+	_ gomkore.HashableArtefact = DirTree{}
+)
 
 func DirFiles(dir, match string, pathMax int) DirTree {
 	res := DirTree{Dir: dir}
@@ -48,7 +52,7 @@ func (d DirTree) List(in *gomkore.Project) (ls []string, err error) {
 	if err != nil {
 		return nil, err
 	}
-	err = d.ls(root, func(p string, e fs.DirEntry) error {
+	err = d.ls(in.FileSystem(), root, func(p string, e fs.DirEntry) error {
 		p, err := in.RelPath(p)
 		if err != nil {
 			return err
@@ -69,7 +73,7 @@ func (d DirTree) Contains(in *gomkore.Project, a Artefact) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	stat, err := os.Stat(aPrj)
+	stat, err := in.FileSystem().Stat(aPrj)
 	if err != nil {
 		return false, err
 	}
@@ -85,7 +89,7 @@ func (d DirTree) Goals(in *gomkore.Project) (gs []*gomkore.Goal, err error) {
 	if err != nil {
 		return nil, err
 	}
-	err = d.ls(root, func(p string, e fs.DirEntry) error {
+	err = d.ls(in.FileSystem(), root, func(p string, e fs.DirEntry) error {
 		p, err := in.RelPath(p)
 		if err != nil {
 			return err
@@ -134,7 +138,7 @@ func (d DirTree) StateAt(in *gomkore.Project) (t time.Time, err error) {
 	if err != nil {
 		return time.Time{}, err
 	}
-	err = d.ls(root, func(_ string, e fs.DirEntry) error {
+	err = d.ls(in.FileSystem(), root, func(_ string, e fs.DirEntry) error {
 		if info, err := e.Info(); err != nil {
 			return err
 		} else if mt := info.ModTime(); mt.After(t) {
@@ -148,12 +152,22 @@ func (d DirTree) StateAt(in *gomkore.Project) (t time.Time, err error) {
 	return t, nil
 }
 
+// WriteHash writes d's filter and the paths and contents of all entries in d
+// to h.
+func (d DirTree) WriteHash(h hash.Hash, in *gomkore.Project) (bool, error) {
+	root, err := in.AbsPath(d.Path())
+	if err != nil {
+		return false, err
+	}
+	return hashDir(h, in.FileSystem(), root, d.Filter, d.ls)
+}
+
 func (d DirTree) Exists(in *gomkore.Project) (bool, error) {
 	ap, err := in.AbsPath(d.Path())
 	if err != nil {
 		return false, err
 	}
-	st, err := os.Stat(ap)
+	st, err := in.FileSystem().Stat(ap)
 	switch {
 	case err == nil:
 		if !st.IsDir() {
@@ -171,14 +185,15 @@ func (d DirTree) Remove(in *gomkore.Project) error {
 	if err != nil {
 		return err
 	}
-	err = d.ls(prjDir, func(p string, _ fs.DirEntry) error {
+	fsys := in.FileSystem()
+	err = d.ls(fsys, prjDir, func(p string, _ fs.DirEntry) error {
 		p = filepath.Join(prjDir, p)
-		return os.Remove(p)
+		return fsys.Remove(p)
 	})
 	if err != nil {
 		return err
 	}
-	return rmDirIfEmpty(prjDir)
+	return rmDirIfEmpty(fsys, prjDir)
 }
 
 func (d DirTree) Moved(strip, dest Directory) (DirTree, error) {
@@ -202,8 +217,8 @@ func (d DirTree) Moved(strip, dest Directory) (DirTree, error) {
 	}, nil
 }
 
-func (d DirTree) ls(root string, do func(string, fs.DirEntry) error) error {
-	return filepath.WalkDir(root, func(path string, e fs.DirEntry, err error) error {
+func (d DirTree) ls(fsys gomkore.FileSystem, root string, do func(string, fs.DirEntry) error) error {
+	return walkDir(fsys, root, func(path string, e fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -20,7 +20,7 @@ func TestDirTree_ls(t *testing.T) {
 	for _, dttf := range dirTreeTestFiles {
 		expect = append(expect, testerr.Shall1(filepath.Rel("ls", dttf)).BeNil(t))
 	}
-	d.ls("testdata/ls", func(s string, e fs.DirEntry) error {
+	d.ls(gomkore.OSFileSystem{}, "testdata/ls", func(s string, e fs.DirEntry) error {
 		if e.IsDir() {
 			return nil
 		}
@@ -3,6 +3,7 @@ package mkfs
 import (
 	"errors"
 	"fmt"
+	"hash"
 	"os"
 	"path/filepath"
 	"time"
@@ -12,7 +13,10 @@ import (
 
 type File string
 
-var _ Artefact = File("")
+var (
+	_ Artefact                 = File("")
+	_ gomkore.HashableArtefact = File("")
+)
 
 func (f File) Key() any { return f }
 
@@ -28,7 +32,7 @@ func (f File) StateAt(in *gomkore.Project) (time.Time, error) {
 	if err != nil {
 		return time.Time{}, err
 	}
-	st, err := os.Stat(ap)
+	st, err := in.FileSystem().Stat(ap)
 	switch {
 	case err != nil:
 		if errors.Is(err, os.ErrNotExist) {
@@ -41,12 +45,25 @@ func (f File) StateAt(in *gomkore.Project) (time.Time, error) {
 	return st.ModTime(), nil
 }
 
+// WriteHash writes the content of f to h.
+func (f File) WriteHash(h hash.Hash, in *gomkore.Project) (bool, error) {
+	ap, err := in.AbsPath(f.Path())
+	if err != nil {
+		return false, err
+	}
+	err = hashFile(h, in.FileSystem(), ap)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (f File) Exists(in *gomkore.Project) (bool, error) {
 	ap, err := in.AbsPath(f.Path())
 	if err != nil {
 		return false, err
 	}
-	st, err := os.Stat(ap)
+	st, err := in.FileSystem().Stat(ap)
 	switch {
 	case err == nil:
 		if st.IsDir() {
@@ -64,7 +81,7 @@ func (f File) Remove(in *gomkore.Project) error {
 	if err != nil {
 		return err
 	}
-	return os.Remove(ap)
+	return in.FileSystem().Remove(ap)
 }
 
 func (f File) Moved(strip, dest Directory) (File, error) {
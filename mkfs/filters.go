@@ -5,6 +5,7 @@ import (
 	"hash"
 	"io/fs"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -64,7 +65,7 @@ func (fx exts) Ok(_ string, e fs.DirEntry) (bool, error) {
 
 func (fx exts) Hash(h hash.Hash) {
 	fmt.Fprintln(h, "mkfs.Exts")
-	for k := range fx {
+	for _, k := range sortedKeys(fx) {
 		fmt.Fprintln(h, k)
 	}
 }
@@ -138,7 +139,7 @@ func (sp skipPaths) Ok(p string, e fs.DirEntry) (bool, error) {
 
 func (sp skipPaths) Hash(h hash.Hash) {
 	fmt.Fprintln(h, "mkfs.SkipPaths")
-	for p := range sp {
+	for _, p := range sortedKeys(sp) {
 		fmt.Fprintln(h, p)
 	}
 }
@@ -165,7 +166,7 @@ func (sn skipNames) Ok(_ string, e fs.DirEntry) (bool, error) {
 
 func (sn skipNames) Hash(h hash.Hash) {
 	fmt.Fprintln(h, "mkfs.SkipNames")
-	for p := range sn {
+	for _, p := range sortedKeys(sn) {
 		fmt.Fprintln(h, p)
 	}
 }
@@ -225,3 +226,14 @@ func (fs Any) Hash(h hash.Hash) {
 		f.Hash(h)
 	}
 }
+
+// sortedKeys makes hashes of map based filters independent of map iteration
+// order.
+func sortedKeys[M ~map[string]bool](m M) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	slices.Sort(ks)
+	return ks
+}
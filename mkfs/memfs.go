@@ -0,0 +1,325 @@
+package mkfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// MemFS is an in-memory [gomkore.FileSystem] for tests of build graphs. Mount
+// it on a [gomkore.Project] to make all mkfs artefacts and operations work
+// without touching the disk. Modification times can be set explicitly
+// with [MemFS.WriteFile] and [MemFS.SetMTime], so tests need not sleep to get
+// distinct timestamps. The root directory always exists.
+type MemFS struct {
+	// Now returns the modification time of written files and changed
+	// directories. If nil, [time.Now] is used. It is set by [MemFS.Mount].
+	Now func() time.Time
+
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+var _ gomkore.FileSystem = (*MemFS)(nil)
+
+type memNode struct {
+	dir   bool
+	perm  fs.FileMode
+	mtime time.Time
+	data  []byte
+}
+
+var errNotDir = errors.New("not a directory")
+
+// NewMemFS returns an empty file system that contains only the root directory.
+func NewMemFS() *MemFS {
+	return &MemFS{nodes: make(map[string]*memNode)}
+}
+
+// Mount sets mfs as the FS of prj. Modification times are then taken from the
+// clock of prj, see [gomkore.Project.Now], so that a test controls all times
+// with the project's Clock.
+func (mfs *MemFS) Mount(prj *gomkore.Project) {
+	prj.FS = mfs
+	mfs.Now = prj.Now
+}
+
+// WriteFile creates or replaces the file name with data and sets its
+// modification time to mtime, or to the current time if mtime is zero.
+// Missing parent directories are created.
+func (mfs *MemFS) WriteFile(name string, data []byte, mtime time.Time) error {
+	name = filepath.Clean(name)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	if err := mfs.mkdirAll("write", filepath.Dir(name), 0777); err != nil {
+		return err
+	}
+	n, err := mfs.create(name, 0666)
+	if err != nil {
+		return err
+	}
+	n.data = bytes.Clone(data)
+	if !mtime.IsZero() {
+		n.mtime = mtime
+	}
+	return nil
+}
+
+// ReadFile returns the content of file name.
+func (mfs *MemFS) ReadFile(name string) ([]byte, error) {
+	name = filepath.Clean(name)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	n, err := mfs.file("read", name)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(n.data), nil
+}
+
+// SetMTime sets the modification time of the file or directory name.
+func (mfs *MemFS) SetMTime(name string, mtime time.Time) error {
+	name = filepath.Clean(name)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	n := mfs.nodes[name]
+	if n == nil {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+	n.mtime = mtime
+	return nil
+}
+
+func (mfs *MemFS) Stat(name string) (fs.FileInfo, error) {
+	name = filepath.Clean(name)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	n := mfs.node(name)
+	if n == nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return memInfo{name: filepath.Base(name), n: *n}, nil
+}
+
+func (mfs *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	name = filepath.Clean(name)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	n := mfs.node(name)
+	switch {
+	case n == nil:
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	case !n.dir:
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+	var es []fs.DirEntry
+	for p, c := range mfs.nodes {
+		if p != name && filepath.Dir(p) == name {
+			es = append(es, fs.FileInfoToDirEntry(memInfo{name: filepath.Base(p), n: *c}))
+		}
+	}
+	slices.SortFunc(es, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return es, nil
+}
+
+func (mfs *MemFS) Open(name string) (io.ReadCloser, error) {
+	data, err := mfs.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (mfs *MemFS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	name = filepath.Clean(name)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	if _, err := mfs.create(name, perm); err != nil {
+		return nil, err
+	}
+	return &memWriter{mfs: mfs, name: name}, nil
+}
+
+func (mfs *MemFS) CreateNew(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	name = filepath.Clean(name)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	if mfs.node(name) != nil {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrExist}
+	}
+	if _, err := mfs.create(name, perm); err != nil {
+		return nil, err
+	}
+	return &memWriter{mfs: mfs, name: name}, nil
+}
+
+func (mfs *MemFS) Rename(oldname, newname string) error {
+	oldname, newname = filepath.Clean(oldname), filepath.Clean(newname)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	n, err := mfs.file("rename", oldname)
+	if err != nil {
+		return err
+	}
+	if oldname == newname {
+		return nil
+	}
+	dir := filepath.Dir(newname)
+	if d := mfs.node(dir); d == nil {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrNotExist}
+	} else if !d.dir {
+		return &fs.PathError{Op: "rename", Path: newname, Err: errNotDir}
+	}
+	if m := mfs.nodes[newname]; m != nil && m.dir {
+		return &fs.PathError{Op: "rename", Path: newname, Err: errors.New("is a directory")}
+	}
+	delete(mfs.nodes, oldname)
+	mfs.nodes[newname] = n
+	mfs.touchDir(filepath.Dir(oldname))
+	mfs.touchDir(dir)
+	return nil
+}
+
+func (mfs *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	name = filepath.Clean(name)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	return mfs.mkdirAll("mkdir", name, perm)
+}
+
+func (mfs *MemFS) Remove(name string) error {
+	name = filepath.Clean(name)
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+	n := mfs.nodes[name]
+	if n == nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if n.dir {
+		for p := range mfs.nodes {
+			if p != name && filepath.Dir(p) == name {
+				return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+			}
+		}
+	}
+	delete(mfs.nodes, name)
+	mfs.touchDir(filepath.Dir(name))
+	return nil
+}
+
+func (mfs *MemFS) now() time.Time {
+	if mfs.Now == nil {
+		return time.Now()
+	}
+	return mfs.Now()
+}
+
+func isRootPath(p string) bool { return filepath.Dir(p) == p }
+
+func (mfs *MemFS) node(name string) *memNode {
+	if n := mfs.nodes[name]; n != nil {
+		return n
+	}
+	if isRootPath(name) {
+		return &memNode{dir: true, perm: 0777}
+	}
+	return nil
+}
+
+func (mfs *MemFS) file(op, name string) (*memNode, error) {
+	switch n := mfs.nodes[name]; {
+	case n == nil:
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	case n.dir:
+		return nil, &fs.PathError{Op: op, Path: name, Err: errors.New("is a directory")}
+	default:
+		return n, nil
+	}
+}
+
+func (mfs *MemFS) create(name string, perm fs.FileMode) (*memNode, error) {
+	dir := filepath.Dir(name)
+	if d := mfs.node(dir); d == nil {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrNotExist}
+	} else if !d.dir {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: errNotDir}
+	}
+	n := mfs.nodes[name]
+	switch {
+	case n == nil:
+		n = &memNode{perm: perm}
+		mfs.nodes[name] = n
+		mfs.touchDir(dir)
+	case n.dir:
+		return nil, &fs.PathError{Op: "create", Path: name, Err: errors.New("is a directory")}
+	}
+	n.data, n.mtime = nil, mfs.now()
+	return n, nil
+}
+
+func (mfs *MemFS) mkdirAll(op, name string, perm fs.FileMode) error {
+	if n := mfs.node(name); n != nil {
+		if !n.dir {
+			return &fs.PathError{Op: op, Path: name, Err: errNotDir}
+		}
+		return nil
+	}
+	dir := filepath.Dir(name)
+	if err := mfs.mkdirAll(op, dir, perm); err != nil {
+		return err
+	}
+	mfs.nodes[name] = &memNode{dir: true, perm: perm, mtime: mfs.now()}
+	mfs.touchDir(dir)
+	return nil
+}
+
+func (mfs *MemFS) touchDir(name string) {
+	if d := mfs.nodes[name]; d != nil {
+		d.mtime = mfs.now()
+	}
+}
+
+type memWriter struct {
+	mfs  *MemFS
+	name string
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	w.mfs.mu.Lock()
+	defer w.mfs.mu.Unlock()
+	n, err := w.mfs.file("write", w.name)
+	if err != nil {
+		return 0, err
+	}
+	n.data = append(n.data, p...)
+	n.mtime = w.mfs.now()
+	return len(p), nil
+}
+
+func (w *memWriter) Close() error { return nil }
+
+type memInfo struct {
+	name string
+	n    memNode
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return int64(len(i.n.data)) }
+func (i memInfo) ModTime() time.Time { return i.n.mtime }
+func (i memInfo) IsDir() bool        { return i.n.dir }
+func (i memInfo) Sys() any           { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.n.dir {
+		return fs.ModeDir | i.n.perm
+	}
+	return i.n.perm
+}
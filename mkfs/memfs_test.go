@@ -0,0 +1,106 @@
+package mkfs
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/gomk/gomktest"
+	"git.fractalqb.de/fractalqb/testerr"
+)
+
+func TestMemFS(t *testing.T) {
+	clock := gomktest.NewClock(gomktest.Time(100))
+	mfs := NewMemFS()
+	testerr.Shall(mfs.WriteFile("/prj/src/a.txt", []byte("A"), gomktest.Time(1))).BeNil(t)
+	testerr.Shall(mfs.WriteFile("/prj/src/b.txt", []byte("B"), gomktest.Time(2))).BeNil(t)
+	prj := gomkore.NewProject("/prj")
+	prj.Clock = clock
+	mfs.Mount(prj)
+
+	if st := testerr.Shall1(File("src/a.txt").StateAt(prj)).BeNil(t); !st.Equal(gomktest.Time(1)) {
+		t.Errorf("unexpected file state %s", st)
+	}
+	src := DirList{Dir: "src", Filter: IsDir(false)}
+	if st := testerr.Shall1(src.StateAt(prj)).BeNil(t); !st.Equal(gomktest.Time(2)) {
+		t.Errorf("unexpected dir list state %s", st)
+	}
+	ls := testerr.Shall1(src.List(prj)).BeNil(t)
+	if !slices.Equal(ls, []string{"src/a.txt", "src/b.txt"}) {
+		t.Errorf("unexpected listing %v", ls)
+	}
+
+	aGoal := testerr.Shall1(prj.Goal(File("src/a.txt"))).BeNil(t)
+	bGoal := testerr.Shall1(prj.Goal(File("src/b.txt"))).BeNil(t)
+	outGoal := testerr.Shall1(prj.Goal(File("out/ab.txt"))).BeNil(t)
+	testerr.Shall1(prj.NewAction(
+		[]*gomkore.Goal{aGoal, bGoal},
+		[]*gomkore.Goal{outGoal},
+		Copy{MkDirMode: 0777},
+	)).BeNil(t)
+	build := func() *gomktest.Recorder {
+		return testerr.Shall1(gomktest.Build(t, prj, nil, "out/ab.txt")).BeNil(t)
+	}
+
+	build().ExpectRanOnce(t, "FS copy")
+	if data := testerr.Shall1(mfs.ReadFile("/prj/out/ab.txt")).BeNil(t); string(data) != "AB" {
+		t.Errorf("unexpected copy '%s'", data)
+	}
+	if st := testerr.Shall1(outGoal.Artefact.StateAt(prj)).BeNil(t); !st.Equal(clock.Now()) {
+		t.Errorf("unexpected copy state %s", st)
+	}
+
+	build().ExpectUpToDate(t, "out/ab.txt")
+
+	clock.Set(gomktest.Time(200))
+	testerr.Shall(mfs.SetMTime("/prj/src/a.txt", gomktest.Time(150))).BeNil(t)
+	rec := build()
+	rec.ExpectUpdated(t, "out/ab.txt")
+	rec.ExpectRanOnce(t, "FS copy")
+
+	testerr.Shall(mfs.SetMTime("/prj/src/b.txt", gomktest.Time(200))).BeNil(t)
+	build().ExpectUpToDate(t, "out/ab.txt")
+	prj.Times = &gomkore.TimePolicy{EqualOutdated: true}
+	build().ExpectUpdated(t, "out/ab.txt")
+	prj.Times = &gomkore.TimePolicy{Tolerance: time.Second}
+	testerr.Shall(mfs.SetMTime("/prj/src/b.txt", clock.Advance(time.Second))).BeNil(t)
+	build().ExpectUpToDate(t, "out/ab.txt")
+
+	out := outGoal.Artefact.(File)
+	testerr.Shall(out.Remove(prj)).BeNil(t)
+	if ok := testerr.Shall1(out.Exists(prj)).BeNil(t); ok {
+		t.Error("removed file still exists")
+	}
+	if empty := testerr.Shall1(isDirEmpty(mfs, "/prj/out")).BeNil(t); !empty {
+		t.Error("directory not empty after remove")
+	}
+}
+
+func TestMemFS_state(t *testing.T) {
+	mfs := NewMemFS()
+	testerr.Shall(mfs.WriteFile("/prj/a.txt", []byte("A"), gomktest.Time(1))).BeNil(t)
+	prj := gomkore.NewProject("/prj")
+	mfs.Mount(prj)
+	prj.State = testerr.Shall1(gomkore.OpenState(prj, "")).BeNil(t)
+	aGoal := testerr.Shall1(prj.Goal(File("a.txt"))).BeNil(t)
+	bGoal := testerr.Shall1(prj.Goal(File("b.txt"))).BeNil(t)
+	act := testerr.Shall1(prj.NewAction(
+		[]*gomkore.Goal{aGoal},
+		[]*gomkore.Goal{bGoal},
+		Copy{},
+	)).BeNil(t)
+	testerr.Shall1(gomktest.Build(t, prj, nil, "b.txt")).BeNil(t)
+
+	if _, err := mfs.ReadFile("/prj/" + gomkore.StateFile); err != nil {
+		t.Fatal(err)
+	}
+	ls := testerr.Shall1(mfs.ReadDir("/prj")).BeNil(t)
+	if len(ls) != 3 {
+		t.Errorf("unexpected files besides the state: %v", ls)
+	}
+	st := testerr.Shall1(gomkore.OpenState(prj, "")).BeNil(t)
+	if _, ok := st.Action(act); !ok {
+		t.Error("action state not persisted")
+	}
+}
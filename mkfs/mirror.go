@@ -5,8 +5,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io/fs"
-	"os"
 	"path/filepath"
 	"time"
 
@@ -20,7 +20,10 @@ type Mirror struct {
 	ExtMap map[string]string
 }
 
-var _ Artefact = Mirror{}
+var (
+	_ Artefact                 = Mirror{}
+	_ gomkore.HashableArtefact = Mirror{}
+)
 
 func (m Mirror) Key() any {
 	h := md5.New()
@@ -38,7 +41,7 @@ func (m Mirror) StateAt(in *gomkore.Project) (t time.Time, err error) {
 		if err != nil {
 			return err
 		}
-		st, err := os.Stat(abs)
+		st, err := in.FileSystem().Stat(abs)
 		if err != nil {
 			return err
 		}
@@ -53,6 +56,27 @@ func (m Mirror) StateAt(in *gomkore.Project) (t time.Time, err error) {
 	return
 }
 
+// WriteHash writes the paths and contents of all mirrored files to h.
+func (m Mirror) WriteHash(h hash.Hash, in *gomkore.Project) (bool, error) {
+	err := m.ls(in, func(rel string) error {
+		abs, err := in.AbsPath(rel)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(h, filepath.ToSlash(rel))
+		if st, err := in.FileSystem().Stat(abs); err != nil {
+			return err
+		} else if st.IsDir() {
+			return nil
+		}
+		return hashFile(h, in.FileSystem(), abs)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (m Mirror) Exists(in *gomkore.Project) (ok bool, err error) {
 	t, err := m.StateAt(in)
 	if err != nil {
@@ -67,7 +91,7 @@ func (m Mirror) Remove(in *gomkore.Project) error {
 		if err != nil {
 			return err
 		}
-		return os.Remove(abs)
+		return in.FileSystem().Remove(abs)
 	})
 }
 
@@ -96,7 +120,7 @@ func (m Mirror) ls(in *gomkore.Project, do func(rel string) error) error {
 	}
 	switch dest := m.Dest.(type) {
 	case DirList:
-		return m.Orig.ls(orig, func(_ string, e fs.DirEntry) error {
+		return m.Orig.ls(in.FileSystem(), orig, func(_ string, e fs.DirEntry) error {
 			e = m.mapExtE(e)
 			p := filepath.Join(dest.Path(), e.Name())
 			if dest.Filter != nil {
@@ -111,7 +135,7 @@ func (m Mirror) ls(in *gomkore.Project, do func(rel string) error) error {
 			}
 		})
 	case DirTree:
-		return m.Orig.ls(orig, func(p string, e fs.DirEntry) error {
+		return m.Orig.ls(in.FileSystem(), orig, func(p string, e fs.DirEntry) error {
 			e = m.mapExtE(e)
 			p = m.mapExtP(p)
 			p = filepath.Join(orig, p)
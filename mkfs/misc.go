@@ -3,6 +3,7 @@ package mkfs
 import (
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/fs"
 	"os"
@@ -21,7 +22,7 @@ func Stat(a Artefact, in *gomkore.Project) (fs.FileInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	return os.Stat(p)
+	return in.FileSystem().Stat(p)
 }
 
 func Exists(a Artefact, in *gomkore.Project) (bool, error) {
@@ -52,7 +53,7 @@ type Directory interface {
 	List(in *gomkore.Project) ([]string, error)
 	Contains(in *gomkore.Project, a Artefact) (bool, error)
 
-	ls(string, func(string, fs.DirEntry) error) error
+	ls(gomkore.FileSystem, string, func(string, fs.DirEntry) error) error
 }
 
 func movedPath(path, strip, dest string) (string, error) {
@@ -65,25 +66,96 @@ func movedPath(path, strip, dest string) (string, error) {
 	return filepath.Join(dest, path), nil
 }
 
-func rmDirIfEmpty(path string) error {
-	if ok, err := isDirEmpty(path); err != nil {
+func hashFile(h hash.Hash, fsys gomkore.FileSystem, path string) error {
+	r, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(h, r)
+	return err
+}
+
+// hashDir writes filter and all entries listed by ls to h.
+func hashDir(
+	h hash.Hash,
+	fsys gomkore.FileSystem,
+	root string,
+	filter Filter,
+	ls func(gomkore.FileSystem, string, func(string, fs.DirEntry) error) error,
+) (bool, error) {
+	if filter != nil {
+		filter.Hash(h)
+	}
+	err := ls(fsys, root, func(p string, e fs.DirEntry) error {
+		fmt.Fprintln(h, filepath.ToSlash(p))
+		if e.IsDir() {
+			return nil
+		}
+		return hashFile(h, fsys, filepath.Join(root, p))
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func rmDirIfEmpty(fsys gomkore.FileSystem, path string) error {
+	if ok, err := isDirEmpty(fsys, path); err != nil {
 		return err
 	} else if !ok {
 		return nil
 	}
-	return os.Remove(path)
+	return fsys.Remove(path)
 }
 
-func isDirEmpty(path string) (bool, error) {
-	dir, err := os.Open(path)
+func isDirEmpty(fsys gomkore.FileSystem, path string) (bool, error) {
+	es, err := fsys.ReadDir(path)
 	if err != nil {
 		return false, err
 	}
-	defer dir.Close()
-	if _, err = dir.ReadDir(1); errors.Is(err, io.EOF) {
-		return true, nil
+	return len(es) == 0, nil
+}
+
+// walkDir works like [filepath.WalkDir] on fsys.
+func walkDir(fsys gomkore.FileSystem, root string, fn fs.WalkDirFunc) error {
+	info, err := fsys.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDirEntry(fsys, root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if err == fs.SkipDir || err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walkDirEntry(fsys gomkore.FileSystem, path string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(path, d, nil); err != nil || !d.IsDir() {
+		if err == fs.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		if err = fn(path, d, err); err != nil {
+			if err == fs.SkipDir && d.IsDir() {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, e := range entries {
+		if err := walkDirEntry(fsys, filepath.Join(path, e.Name()), e, fn); err != nil {
+			if err == fs.SkipDir {
+				break
+			}
+			return err
+		}
 	}
-	return false, err
+	return nil
 }
 
 type infoEntry struct{ fs.FileInfo }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"hash"
 	"io/fs"
-	"os"
 	"path/filepath"
 
 	"git.fractalqb.de/fractalqb/gomk/gomkore"
@@ -36,14 +35,14 @@ func (md *MkDirs) Do(tr *gomkore.Trace, a *gomkore.Action, env *gomkore.Env) err
 				return err
 			}
 			tr.Debug("create `directory`", `directory`, path)
-			return os.MkdirAll(path, md.MkDirMode)
+			return prj.FileSystem().MkdirAll(path, md.MkDirMode)
 		case Directory:
 			path, err := prj.AbsPath(res.Path())
 			if err != nil {
 				return err
 			}
 			tr.Debug("create `directory`", `directory`, path)
-			return os.MkdirAll(path, md.MkDirMode)
+			return prj.FileSystem().MkdirAll(path, md.MkDirMode)
 		default:
 			return fmt.Errorf("illegal MkDirs result: %T", res)
 		}
@@ -0,0 +1,185 @@
+package gomk
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// MultiTracer forwards every event to all of its tracers in order. The action
+// environments are chained: Each tracer's SetupActionEnv gets the environment
+// returned by the previous tracer, so each one can wrap the Out and Err
+// writers of the action. CloseActionEnv is called in reverse order, each
+// tracer with the environment it returned from SetupActionEnv.
+type MultiTracer struct {
+	tracers []gomkore.Tracer
+
+	mu   sync.Mutex
+	envs map[*gomkore.Trace][]*gomkore.Env
+}
+
+var _ gomkore.Tracer = (*MultiTracer)(nil)
+
+func NewMultiTracer(tracers ...gomkore.Tracer) *MultiTracer {
+	return &MultiTracer{
+		tracers: tracers,
+		envs:    make(map[*gomkore.Trace][]*gomkore.Env),
+	}
+}
+
+// Tracers returns the tracers that tr forwards events to.
+func (tr *MultiTracer) Tracers() []gomkore.Tracer { return tr.tracers }
+
+func (tr *MultiTracer) Debug(t *gomkore.Trace, msg string, args ...any) {
+	for _, x := range tr.tracers {
+		x.Debug(t, msg, args...)
+	}
+}
+
+func (tr *MultiTracer) Info(t *gomkore.Trace, msg string, args ...any) {
+	for _, x := range tr.tracers {
+		x.Info(t, msg, args...)
+	}
+}
+
+func (tr *MultiTracer) Warn(t *gomkore.Trace, msg string, args ...any) {
+	for _, x := range tr.tracers {
+		x.Warn(t, msg, args...)
+	}
+}
+
+func (tr *MultiTracer) StartProject(t *gomkore.Trace, p *gomkore.Project, activity string) {
+	for _, x := range tr.tracers {
+		x.StartProject(t, p, activity)
+	}
+}
+
+func (tr *MultiTracer) DoneProject(t *gomkore.Trace, p *gomkore.Project, activity string, dt time.Duration) {
+	for _, x := range tr.tracers {
+		x.DoneProject(t, p, activity, dt)
+	}
+}
+
+// SetupActionEnv chains the SetupActionEnv calls of all tracers. If one of them
+// fails, the environments set up so far are closed and the error is returned.
+func (tr *MultiTracer) SetupActionEnv(t *gomkore.Trace, env *gomkore.Env) (*gomkore.Env, error) {
+	envs := make([]*gomkore.Env, 0, len(tr.tracers))
+	for _, x := range tr.tracers {
+		e, err := x.SetupActionEnv(t, env)
+		if err != nil {
+			return nil, errors.Join(err, tr.close(t, envs))
+		}
+		envs = append(envs, e)
+		env = e
+	}
+	tr.mu.Lock()
+	tr.envs[t] = envs
+	tr.mu.Unlock()
+	return env, nil
+}
+
+// CloseActionEnv calls the CloseActionEnv of all tracers in reverse order and
+// joins their errors.
+func (tr *MultiTracer) CloseActionEnv(t *gomkore.Trace, env *gomkore.Env) error {
+	tr.mu.Lock()
+	envs, ok := tr.envs[t]
+	delete(tr.envs, t)
+	tr.mu.Unlock()
+	if !ok {
+		envs = make([]*gomkore.Env, len(tr.tracers))
+		for i := range envs {
+			envs[i] = env
+		}
+	}
+	return tr.close(t, envs)
+}
+
+func (tr *MultiTracer) close(t *gomkore.Trace, envs []*gomkore.Env) error {
+	var errs []error
+	for i := len(envs) - 1; i >= 0; i-- {
+		if err := tr.tracers[i].CloseActionEnv(t, envs[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (tr *MultiTracer) RunAction(t *gomkore.Trace, a *gomkore.Action) {
+	for _, x := range tr.tracers {
+		x.RunAction(t, a)
+	}
+}
+
+func (tr *MultiTracer) RunImplicitAction(t *gomkore.Trace, a *gomkore.Action) {
+	for _, x := range tr.tracers {
+		x.RunImplicitAction(t, a)
+	}
+}
+
+func (tr *MultiTracer) ActionDone(t *gomkore.Trace, a *gomkore.Action, dt time.Duration, err error) {
+	for _, x := range tr.tracers {
+		x.ActionDone(t, a, dt, err)
+	}
+}
+
+func (tr *MultiTracer) ScheduleResTimeZero(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	for _, x := range tr.tracers {
+		x.ScheduleResTimeZero(t, a, res)
+	}
+}
+
+func (tr *MultiTracer) ScheduleNotPremises(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	for _, x := range tr.tracers {
+		x.ScheduleNotPremises(t, a, res)
+	}
+}
+
+func (tr *MultiTracer) SchedulePreTimeZero(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+	for _, x := range tr.tracers {
+		x.SchedulePreTimeZero(t, a, res, pre)
+	}
+}
+
+func (tr *MultiTracer) ScheduleOutdated(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+	for _, x := range tr.tracers {
+		x.ScheduleOutdated(t, a, res, pre)
+	}
+}
+
+func (tr *MultiTracer) ScheduleHashChanged(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	for _, x := range tr.tracers {
+		x.ScheduleHashChanged(t, a, res)
+	}
+}
+
+func (tr *MultiTracer) CheckGoal(t *gomkore.Trace, g *gomkore.Goal) {
+	for _, x := range tr.tracers {
+		x.CheckGoal(t, g)
+	}
+}
+
+func (tr *MultiTracer) GoalUpToDate(t *gomkore.Trace, g *gomkore.Goal) {
+	for _, x := range tr.tracers {
+		x.GoalUpToDate(t, g)
+	}
+}
+
+func (tr *MultiTracer) GoalNeedsActions(t *gomkore.Trace, g *gomkore.Goal, n int) {
+	for _, x := range tr.tracers {
+		x.GoalNeedsActions(t, g, n)
+	}
+}
+
+func (tr *MultiTracer) GoalDone(t *gomkore.Trace, g *gomkore.Goal, dt time.Duration, err error) {
+	for _, x := range tr.tracers {
+		x.GoalDone(t, g, dt, err)
+	}
+}
+
+func (tr *MultiTracer) RemoveArtefact(t *gomkore.Trace, g *gomkore.Goal) {
+	for _, x := range tr.tracers {
+		x.RemoveArtefact(t, g)
+	}
+}
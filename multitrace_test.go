@@ -0,0 +1,68 @@
+package gomk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/testerr"
+)
+
+type envTracer struct {
+	TestTracer
+	name string
+	log  *[]string
+	envs map[*gomkore.Env]bool
+}
+
+func (tr envTracer) SetupActionEnv(t *gomkore.Trace, env *gomkore.Env) (*gomkore.Env, error) {
+	*tr.log = append(*tr.log, "setup "+tr.name)
+	e := env.Sub()
+	e.Out = newPrefixWriterString(e.Out, tr.name+": ")
+	tr.envs[e] = true
+	return e, nil
+}
+
+func (tr envTracer) CloseActionEnv(t *gomkore.Trace, env *gomkore.Env) error {
+	*tr.log = append(*tr.log, "close "+tr.name)
+	if !tr.envs[env] {
+		return fmt.Errorf("%s: close foreign env", tr.name)
+	}
+	return nil
+}
+
+func TestMultiTracer(t *testing.T) {
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		prj.AbstractGoal("x").By(OpFunc("echo", func(_ *gomkore.Trace, _ *gomkore.Action, env *gomkore.Env) error {
+			_, err := fmt.Fprintln(env.Out, "hello")
+			return err
+		}))
+	})).BeNil(t)
+
+	var (
+		log []string
+		out bytes.Buffer
+	)
+	tr := NewMultiTracer(
+		envTracer{TestTracer{t}, "A", &log, make(map[*gomkore.Env]bool)},
+		envTracer{TestTracer{t}, "B", &log, make(map[*gomkore.Env]bool)},
+	)
+	env := gomkore.DefaultEnv(nil)
+	env.Out = &out
+	build := NewBuilder(gomkore.NewTrace(context.Background(), tr), env)
+	testerr.Shall(build.Project(prj)).BeNil(t)
+
+	if s := out.String(); s != "A: B: hello\n" {
+		t.Errorf("unexpected output '%s'", s)
+	}
+	if !slices.Equal(log, []string{"setup A", "setup B", "close B", "close A"}) {
+		t.Errorf("unexpected call order %v", log)
+	}
+	if l := len(tr.envs); l != 0 {
+		t.Errorf("%d action environments not released", l)
+	}
+}
@@ -0,0 +1,128 @@
+package gomk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// OutputMode controls when the output of actions is written by a
+// [WriteTracer].
+type OutputMode int
+
+const (
+	// OutputDirect writes the output of actions as soon as it is written by
+	// the action's operation. Output of concurrent actions may interleave.
+	OutputDirect OutputMode = iota
+
+	// OutputBuffered captures the output of actions and writes it as one
+	// contiguous block when the action is done.
+	OutputBuffered
+
+	// OutputQuiet captures the output of actions and writes it as one
+	// contiguous block only if the action failed.
+	OutputQuiet
+)
+
+func (m OutputMode) String() string {
+	switch m {
+	case OutputDirect:
+		return "direct"
+	case OutputBuffered:
+		return "buffered"
+	case OutputQuiet:
+		return "quiet"
+	}
+	return fmt.Sprintf("OutputMode(%d)", int(m))
+}
+
+// outputMu makes sure blocks of buffered output do not interleave with each
+// other or with the trace lines of a [WriteTracer].
+var outputMu sync.Mutex
+
+// actionOutput captures the output of one action in the order it was written
+// to Out and Err.
+type actionOutput struct {
+	mu     sync.Mutex
+	chunks []outputChunk
+	all    bytes.Buffer
+}
+
+type outputChunk struct {
+	w io.Writer
+	p []byte
+}
+
+func (o *actionOutput) writer(w io.Writer) *outputWriter {
+	return &outputWriter{o: o, w: w}
+}
+
+// flush writes the captured output to the respective writers and completes
+// an incomplete last line.
+func (o *actionOutput) flush() (err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	var (
+		ws   []io.Writer
+		last = make(map[io.Writer][]byte)
+	)
+	for _, c := range o.chunks {
+		if _, e := c.w.Write(c.p); err == nil {
+			err = e
+		}
+		if _, ok := last[c.w]; !ok {
+			ws = append(ws, c.w)
+		}
+		last[c.w] = c.p
+	}
+	for _, w := range ws {
+		if p := last[w]; p[len(p)-1] != '\n' {
+			if _, e := w.Write([]byte{'\n'}); err == nil {
+				err = e
+			}
+		}
+	}
+	o.chunks, o.all = nil, bytes.Buffer{}
+	return err
+}
+
+// discard drops the captured output without writing it.
+func (o *actionOutput) discard() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.chunks, o.all = nil, bytes.Buffer{}
+}
+
+// outputWriter captures the output for w in its actionOutput.
+type outputWriter struct {
+	o *actionOutput
+	w io.Writer
+}
+
+var _ gomkore.OutputCapture = (*outputWriter)(nil)
+
+func (ow *outputWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	ow.o.mu.Lock()
+	defer ow.o.mu.Unlock()
+	if l := len(ow.o.chunks); l > 0 && ow.o.chunks[l-1].w == ow.w {
+		ow.o.chunks[l-1].p = append(ow.o.chunks[l-1].p, p...)
+	} else {
+		ow.o.chunks = append(ow.o.chunks, outputChunk{w: ow.w, p: bytes.Clone(p)})
+	}
+	ow.o.all.Write(p)
+	return len(p), nil
+}
+
+func (ow *outputWriter) CapturedOutput() []byte {
+	ow.o.mu.Lock()
+	defer ow.o.mu.Unlock()
+	return bytes.Clone(ow.o.all.Bytes())
+}
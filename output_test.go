@@ -0,0 +1,227 @@
+package gomk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/testerr"
+)
+
+func outputProject(t *testing.T) *gomkore.Project {
+	chatty := func(name string, fail bool) gomkore.Operation {
+		return OpFunc(name, func(_ *gomkore.Trace, _ *gomkore.Action, env *gomkore.Env) error {
+			for i := range 3 {
+				fmt.Fprintf(env.Out, "%s %d\n", name, i)
+				time.Sleep(5 * time.Millisecond)
+			}
+			fmt.Fprint(env.Err, name+" done")
+			if fail {
+				return errors.New("broken")
+			}
+			return nil
+		})
+	}
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		prj.AbstractGoal("a").By(chatty("A", false))
+		prj.AbstractGoal("b").By(chatty("B", true))
+	})).BeNil(t)
+	return prj
+}
+
+// syncBuffer is a bytes.Buffer that can be written concurrently, as it happens
+// with [OutputDirect].
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWriteTracer_Output(t *testing.T) {
+	build := func(t *testing.T, mode OutputMode) (string, error) {
+		var out syncBuffer
+		tr := &WriteTracer{W: &out, Output: mode}
+		env := gomkore.DefaultEnv(nil)
+		env.Out, env.Err = &out, &out
+		build := NewBuilder(gomkore.NewTrace(context.Background(), tr), env)
+		build.MaxJobs = 2
+		build.KeepGoing = true
+		err := build.Project(outputProject(t))
+		if err == nil {
+			t.Fatal("build did not fail")
+		}
+		return out.String(), err
+	}
+	block := func(name string) string {
+		var sb strings.Builder
+		for i := range 3 {
+			fmt.Fprintf(&sb, "Out: %s %d\n", name, i)
+		}
+		fmt.Fprintf(&sb, "Err: %s done\n", name)
+		return sb.String()
+	}
+	stripPrefix := func(s string) string {
+		var sb strings.Builder
+		for _, l := range strings.SplitAfter(s, "\n") {
+			if i := strings.Index(l, " Out: "); i >= 0 {
+				l = l[i+1:]
+			} else if i := strings.Index(l, " Err: "); i >= 0 {
+				l = l[i+1:]
+			}
+			sb.WriteString(l)
+		}
+		return sb.String()
+	}
+
+	t.Run("buffered", func(t *testing.T) {
+		out, err := build(t, OutputBuffered)
+		out = stripPrefix(out)
+		for _, name := range []string{"A", "B"} {
+			if !strings.Contains(out, block(name)) {
+				t.Errorf("no contiguous block of %s in:\n%s", name, out)
+			}
+		}
+		var aerr *gomkore.ActionError
+		if !errors.As(err, &aerr) {
+			t.Fatalf("no action error: %s", err)
+		}
+		if s := string(aerr.Output); s != "B 0\nB 1\nB 2\nB done" {
+			t.Errorf("unexpected error output '%s'", s)
+		}
+	})
+	t.Run("quiet", func(t *testing.T) {
+		out, _ := build(t, OutputQuiet)
+		out = stripPrefix(out)
+		if strings.Contains(out, "A 0") {
+			t.Errorf("output of successful action in:\n%s", out)
+		}
+		if !strings.Contains(out, block("B")) {
+			t.Errorf("no contiguous block of B in:\n%s", out)
+		}
+	})
+	t.Run("direct", func(t *testing.T) {
+		_, err := build(t, OutputDirect)
+		var aerr *gomkore.ActionError
+		if !errors.As(err, &aerr) {
+			t.Fatalf("no action error: %s", err)
+		}
+		if aerr.Output != nil {
+			t.Errorf("captured output in direct mode: '%s'", aerr.Output)
+		}
+	})
+}
+
+// outputsTracer keeps the captured outputs of all actions.
+type outputsTracer struct {
+	WriteTracer
+	mu   sync.Mutex
+	outs []*actionOutput
+}
+
+func (tr *outputsTracer) SetupActionEnv(t *gomkore.Trace, env *gomkore.Env) (*gomkore.Env, error) {
+	env, err := tr.WriteTracer.SetupActionEnv(t, env)
+	if w, ok := env.Out.(*outputWriter); ok {
+		tr.mu.Lock()
+		tr.outs = append(tr.outs, w.o)
+		tr.mu.Unlock()
+	}
+	return env, err
+}
+
+func TestWriteTracer_Output_release(t *testing.T) {
+	for _, mode := range []OutputMode{OutputBuffered, OutputQuiet} {
+		t.Run(mode.String(), func(t *testing.T) {
+			var out syncBuffer
+			tr := &outputsTracer{WriteTracer: WriteTracer{W: &out, Output: mode}}
+			env := gomkore.DefaultEnv(nil)
+			env.Out, env.Err = &out, &out
+			build := NewBuilder(gomkore.NewTrace(context.Background(), tr), env)
+			build.KeepGoing = true
+			if err := build.Project(outputProject(t)); err == nil {
+				t.Fatal("build did not fail")
+			}
+			if l := len(tr.outs); l != 2 {
+				t.Fatalf("captured output of %d actions, want 2", l)
+			}
+			for _, o := range tr.outs {
+				if o.chunks != nil || o.all.Cap() > 0 {
+					t.Errorf("captured output not released: %q", o.all.String())
+				}
+			}
+		})
+	}
+}
+
+// slowWriter delays writes to make interleaving of concurrent writes likely.
+type slowWriter struct{ w io.Writer }
+
+func (w slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(100 * time.Microsecond)
+	return w.w.Write(p)
+}
+
+func TestWriteTracer_Output_traceLines(t *testing.T) {
+	const n = 8
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		for i := range n {
+			name := fmt.Sprintf("A%d", i)
+			prj.AbstractGoal(name).By(OpFunc(name, func(_ *gomkore.Trace, _ *gomkore.Action, env *gomkore.Env) error {
+				for i := range 3 {
+					fmt.Fprintf(env.Out, "%s %d\n", name, i)
+				}
+				fmt.Fprintln(env.Err, name+" done")
+				return nil
+			}))
+		}
+	})).BeNil(t)
+	var buf syncBuffer
+	out := slowWriter{&buf}
+	tr := &WriteTracer{W: out, Log: TraceMost, Output: OutputBuffered}
+	env := gomkore.DefaultEnv(nil)
+	env.Out, env.Err = out, out
+	build := NewBuilder(gomkore.NewTrace(context.Background(), tr), env)
+	build.MaxJobs = 4
+	testerr.Shall(build.Project(prj)).BeNil(t)
+
+	lines := strings.Split(buf.String(), "\n")
+	for i := range n {
+		name := fmt.Sprintf("A%d", i)
+		at := slices.IndexFunc(lines, func(l string) bool {
+			return strings.HasSuffix(l, " Out: "+name+" 0")
+		})
+		if at < 0 || at+4 > len(lines) {
+			t.Fatalf("no output of %s in:\n%s", name, buf.String())
+		}
+		for j, l := range lines[at : at+4] {
+			exp := fmt.Sprintf(" Out: %s %d", name, j)
+			if j == 3 {
+				exp = " Err: " + name + " done"
+			}
+			if !strings.HasSuffix(l, exp) {
+				t.Errorf("block of %s interrupted by '%s'", name, l)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package gomk
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// WritePlantUML writes the diagram of prj as PlantUML deployment diagram to w.
+// It shows the same as [Diagrammer.WriteDot]. Tangible goals are drawn as
+// files, actions as cards and implicit actions as dashed edges.
+func (dia *Diagrammer) WritePlantUML(w io.Writer, prj *gomkore.Project) (err error) {
+	defer recoverDiagram(&err)
+	g, err := dia.graph(prj)
+	if err != nil {
+		return err
+	}
+	writePlantUML(w, g, dia.RankDir)
+	return nil
+}
+
+func writePlantUML(w io.Writer, g *diaGraph, rankDir string) {
+	fmt.Fprintf(w, "@startuml \"%s\"\n", escPlantUML(g.name))
+	if rankDir == "LR" || rankDir == "RL" {
+		fmt.Fprintln(w, "left to right direction")
+	}
+	for _, n := range g.nodes {
+		var style []string
+		if n.kind == diaAbstract {
+			style = append(style, "line.dashed")
+		}
+		if n.bold {
+			style = append(style, "line.bold")
+		}
+		if c := n.state.color(); c != "" {
+			style = append(style, "back:"+c)
+		}
+		var attrs string
+		if len(style) > 0 {
+			attrs = " #" + strings.Join(style, ";")
+		}
+		switch n.kind {
+		case diaAbstract:
+			fmt.Fprintf(w, "rectangle \"%s\" as %s%s\n", escPlantUML(n.label), n.id, attrs)
+		case diaTangible:
+			fmt.Fprintf(w, "file \"%s%s\\n%s\" as %s%s\n",
+				n.atfType,
+				n.updMode,
+				escPlantUML(n.label),
+				n.id,
+				attrs,
+			)
+		case diaAction:
+			fmt.Fprintf(w, "card \"%s\" as %s%s\n", escPlantUML(n.label), n.id, attrs)
+		case diaJoint:
+			fmt.Fprintf(w, "circle \" \" as %s\n", n.id)
+		}
+	}
+	for _, e := range g.edges {
+		var head string
+		if !e.noHead {
+			head = ">"
+		}
+		var arrow string
+		switch {
+		case e.trigger && e.implicit:
+			arrow = fmt.Sprintf("-[#%s,dashed,thickness=2]-%s", diaTriggerColor, head)
+		case e.trigger:
+			arrow = fmt.Sprintf("-[#%s,thickness=2]-%s", diaTriggerColor, head)
+		case e.implicit:
+			arrow = ".." + head
+		default:
+			arrow = "--" + head
+		}
+		if e.order > 0 {
+			fmt.Fprintf(w, "%s %s %s : %d\n", e.from, arrow, e.to, e.order)
+		} else {
+			fmt.Fprintf(w, "%s %s %s\n", e.from, arrow, e.to)
+		}
+	}
+	fmt.Fprintln(w, "@enduml")
+}
+
+var plantUMLEsc = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "&#34;",
+)
+
+func escPlantUML(s string) string { return plantUMLEsc.Replace(s) }
@@ -0,0 +1,349 @@
+package gomk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// ProgressTracer shows a live status area at the bottom of a terminal with the
+// running actions and their elapsed time, the number of done, pending and
+// failed actions and an estimated time to completion. Everything else, i.e.
+// the lines of the embedded [WriteTracer] and the output of actions, is printed
+// above the status area. If the terminal is not a TTY, ProgressTracer behaves
+// like the embedded WriteTracer. Call [ProgressTracer.Close] after the build
+// to remove the status area and print a summary.
+type ProgressTracer struct {
+	WriteTracer
+
+	// Term is the terminal to draw the status area on.
+	Term io.Writer
+
+	// Live enables the status area. It is set by [NewProgressTracer] if Term
+	// is a TTY.
+	Live bool
+
+	// Width is the maximum width of status lines. Longer lines are cut off.
+	Width int
+
+	// MaxShown is the maximum number of running actions listed in the status
+	// area.
+	MaxShown int
+
+	mu       sync.Mutex
+	start    time.Time
+	status   int
+	ticker   *time.Ticker
+	stop     chan struct{}
+	closed   bool
+	running  []progressRun
+	pending  map[*gomkore.Action]bool
+	done     int
+	failed   int
+	busy     time.Duration
+	parallel int
+	lines    map[*gomkore.Trace][]*progressLine
+}
+
+var _ gomkore.Tracer = (*ProgressTracer)(nil)
+
+type progressRun struct {
+	id    uint64
+	act   *gomkore.Action
+	start time.Time
+}
+
+const progressTick = 200 * time.Millisecond
+
+func NewProgressTracer(term io.Writer, log TraceLevel) *ProgressTracer {
+	tr := &ProgressTracer{
+		Term:     term,
+		Live:     isTerminal(term),
+		Width:    80,
+		MaxShown: 8,
+		start:    time.Now(),
+		pending:  make(map[*gomkore.Action]bool),
+		lines:    make(map[*gomkore.Trace][]*progressLine),
+	}
+	tr.WriteTracer = WriteTracer{
+		W:   &progressLine{tr: tr, w: term},
+		Log: log,
+	}
+	return tr
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+// Close removes the status area and prints a summary of the actions if tr is
+// live. The status area is not shown again unless another build starts.
+func (tr *ProgressTracer) Close() error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.closed = true
+	if tr.ticker != nil {
+		tr.ticker.Stop()
+		close(tr.stop)
+		tr.ticker = nil
+	}
+	if !tr.Live {
+		return nil
+	}
+	tr.clear()
+	_, err := fmt.Fprintf(tr.Term, "%d actions done, %d failed, %d not run, took %s\n",
+		tr.done,
+		tr.failed,
+		len(tr.pending)+len(tr.running),
+		time.Since(tr.start).Round(time.Millisecond),
+	)
+	return err
+}
+
+func (tr *ProgressTracer) StartProject(t *gomkore.Trace, p *gomkore.Project, activity string) {
+	tr.WriteTracer.StartProject(t, p, activity)
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.Live && tr.ticker == nil {
+		tr.closed = false
+		tr.ticker = time.NewTicker(progressTick)
+		tr.stop = make(chan struct{})
+		go tr.tick(tr.ticker.C, tr.stop)
+	}
+}
+
+func (tr *ProgressTracer) tick(c <-chan time.Time, stop <-chan struct{}) {
+	for {
+		select {
+		case <-c:
+			tr.mu.Lock()
+			select {
+			case <-stop:
+				// Close was called while waiting for the lock
+			default:
+				tr.redraw()
+			}
+			tr.mu.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// SetupActionEnv makes the action's output appear above the status area if tr
+// is live. Output is written line by line, incomplete lines are completed by
+// CloseActionEnv.
+func (tr *ProgressTracer) SetupActionEnv(t *gomkore.Trace, env *gomkore.Env) (*gomkore.Env, error) {
+	if !tr.Live || (env.Out == nil && env.Err == nil) {
+		return tr.WriteTracer.SetupActionEnv(t, env)
+	}
+	e := env.Sub()
+	var lines []*progressLine
+	if e.Out != nil {
+		l := &progressLine{tr: tr, w: e.Out}
+		e.Out, lines = l, append(lines, l)
+	}
+	if e.Err != nil {
+		l := &progressLine{tr: tr, w: e.Err}
+		e.Err, lines = l, append(lines, l)
+	}
+	tr.mu.Lock()
+	tr.lines[t] = lines
+	tr.mu.Unlock()
+	return tr.WriteTracer.SetupActionEnv(t, e)
+}
+
+func (tr *ProgressTracer) CloseActionEnv(t *gomkore.Trace, env *gomkore.Env) error {
+	err := tr.WriteTracer.CloseActionEnv(t, env)
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for _, l := range tr.lines[t] {
+		if e := l.flush(); err == nil {
+			err = e
+		}
+	}
+	delete(tr.lines, t)
+	return err
+}
+
+func (tr *ProgressTracer) RunAction(t *gomkore.Trace, a *gomkore.Action) {
+	tr.WriteTracer.RunAction(t, a)
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	delete(tr.pending, a)
+	tr.running = append(tr.running, progressRun{id: t.TopID(), act: a, start: time.Now()})
+	tr.parallel = max(tr.parallel, len(tr.running))
+	tr.redraw()
+}
+
+func (tr *ProgressTracer) ActionDone(t *gomkore.Trace, a *gomkore.Action, dt time.Duration, err error) {
+	tr.WriteTracer.ActionDone(t, a, dt, err)
+	if a.Op == nil {
+		return
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	id := t.TopID()
+	tr.running = slices.DeleteFunc(tr.running, func(r progressRun) bool { return r.id == id })
+	if err != nil {
+		tr.failed++
+	} else {
+		tr.done++
+	}
+	tr.busy += dt
+	tr.redraw()
+}
+
+func (tr *ProgressTracer) ScheduleResTimeZero(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	tr.WriteTracer.ScheduleResTimeZero(t, a, res)
+	tr.schedule(t, a)
+}
+
+func (tr *ProgressTracer) ScheduleNotPremises(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	tr.WriteTracer.ScheduleNotPremises(t, a, res)
+	tr.schedule(t, a)
+}
+
+func (tr *ProgressTracer) SchedulePreTimeZero(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+	tr.WriteTracer.SchedulePreTimeZero(t, a, res, pre)
+	tr.schedule(t, a)
+}
+
+func (tr *ProgressTracer) ScheduleOutdated(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+	tr.WriteTracer.ScheduleOutdated(t, a, res, pre)
+	tr.schedule(t, a)
+}
+
+func (tr *ProgressTracer) ScheduleHashChanged(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	tr.WriteTracer.ScheduleHashChanged(t, a, res)
+	tr.schedule(t, a)
+}
+
+// schedule adds a to the pending actions unless it already ran in the build.
+func (tr *ProgressTracer) schedule(t *gomkore.Trace, a *gomkore.Action) {
+	if a.Op == nil || a.LastBuild() >= t.Build() {
+		return
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.pending[a] = true
+	tr.redraw()
+}
+
+// eta estimates the remaining time from the average duration of the finished
+// actions and the observed parallelism. It returns false if there is no
+// estimate yet.
+func (tr *ProgressTracer) eta() (time.Duration, bool) {
+	n := tr.done + tr.failed
+	if n == 0 {
+		return 0, false
+	}
+	avg := tr.busy / time.Duration(n)
+	todo := len(tr.pending) + len(tr.running)
+	return avg * time.Duration(todo) / time.Duration(max(tr.parallel, 1)), true
+}
+
+// clear removes the status area. The cursor is expected to be at the start of
+// the line below the status area.
+func (tr *ProgressTracer) clear() {
+	if tr.status > 0 {
+		fmt.Fprintf(tr.Term, "\x1b[%dA\r\x1b[J", tr.status)
+		tr.status = 0
+	}
+}
+
+func (tr *ProgressTracer) draw() {
+	if !tr.Live || tr.closed {
+		return
+	}
+	now := time.Now()
+	var sb strings.Builder
+	line := func(format string, args ...any) {
+		l := fmt.Sprintf(format, args...)
+		if r := []rune(l); tr.Width > 0 && len(r) > tr.Width {
+			l = string(r[:tr.Width-1]) + "…"
+		}
+		sb.WriteString(l)
+		sb.WriteByte('\n')
+		tr.status++
+	}
+	for i, r := range tr.running {
+		if tr.MaxShown > 0 && i == tr.MaxShown {
+			line("  … and %d more", len(tr.running)-i)
+			break
+		}
+		line("  %6s (%s)", now.Sub(r.start).Round(100*time.Millisecond), r.act)
+	}
+	eta := "?"
+	if d, ok := tr.eta(); ok {
+		eta = d.Round(time.Second).String()
+	}
+	line("[done %d | running %d | pending %d | failed %d] %s ETA %s",
+		tr.done,
+		len(tr.running),
+		len(tr.pending),
+		tr.failed,
+		now.Sub(tr.start).Round(time.Second),
+		eta,
+	)
+	io.WriteString(tr.Term, sb.String())
+}
+
+func (tr *ProgressTracer) redraw() {
+	if tr.Live && !tr.closed {
+		tr.clear()
+		tr.draw()
+	}
+}
+
+// progressLine writes complete lines to w above the status area of tr.
+type progressLine struct {
+	tr  *ProgressTracer
+	w   io.Writer
+	buf []byte
+}
+
+func (l *progressLine) Write(p []byte) (int, error) {
+	l.tr.mu.Lock()
+	defer l.tr.mu.Unlock()
+	if !l.tr.Live {
+		return l.w.Write(p)
+	}
+	l.buf = append(l.buf, p...)
+	i := bytes.LastIndexByte(l.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	i++
+	err := l.print(l.buf[:i])
+	l.buf = l.buf[:copy(l.buf, l.buf[i:])]
+	return len(p), err
+}
+
+func (l *progressLine) flush() error {
+	if len(l.buf) == 0 {
+		return nil
+	}
+	err := l.print(append(l.buf, '\n'))
+	l.buf = l.buf[:0]
+	return err
+}
+
+func (l *progressLine) print(p []byte) error {
+	l.tr.clear()
+	_, err := l.w.Write(p)
+	l.tr.draw()
+	return err
+}
@@ -0,0 +1,92 @@
+package gomk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/testerr"
+)
+
+func progressProject(t *testing.T) *gomkore.Project {
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		prj.AbstractGoal("a").By(OpFunc("echo", func(_ *gomkore.Trace, _ *gomkore.Action, env *gomkore.Env) error {
+			fmt.Fprint(env.Out, "hello ")
+			fmt.Fprint(env.Out, "world")
+			return nil
+		}))
+		prj.AbstractGoal("b").By(OpFunc("fail", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			return errors.New("broken")
+		}))
+	})).BeNil(t)
+	return prj
+}
+
+func TestProgressTracer(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		var term, out bytes.Buffer
+		tr := NewProgressTracer(&term, TraceImportant)
+		if tr.Live {
+			t.Fatal("buffer is considered a terminal")
+		}
+		env := gomkore.DefaultEnv(nil)
+		env.Out = &out
+		build := NewBuilder(gomkore.NewTrace(context.Background(), tr), env)
+		build.KeepGoing = true
+		if err := build.Project(progressProject(t)); err == nil {
+			t.Fatal("build did not fail")
+		}
+		testerr.Shall(tr.Close()).BeNil(t)
+		if s := term.String(); strings.Contains(s, "\x1b[") {
+			t.Errorf("escape sequences in plain output:\n%s", s)
+		} else if !strings.Contains(s, "run action (echo)") {
+			t.Errorf("missing write tracer lines:\n%s", s)
+		}
+		if s := out.String(); !strings.HasSuffix(s, "Out: hello world") {
+			t.Errorf("unexpected action output '%s'", s)
+		}
+	})
+	t.Run("live", func(t *testing.T) {
+		var term bytes.Buffer
+		tr := NewProgressTracer(&term, TraceNothing)
+		tr.Live = true
+		env := gomkore.DefaultEnv(nil)
+		env.Out = &term
+		build := NewBuilder(gomkore.NewTrace(context.Background(), tr), env)
+		build.KeepGoing = true
+		if err := build.Project(progressProject(t)); err == nil {
+			t.Fatal("build did not fail")
+		}
+		testerr.Shall(tr.Close()).BeNil(t)
+		if tr.done != 1 || tr.failed != 1 || len(tr.pending) != 0 || len(tr.running) != 0 {
+			t.Errorf("unexpected counts: done=%d failed=%d pending=%d running=%d",
+				tr.done, tr.failed, len(tr.pending), len(tr.running))
+		}
+		s := term.String()
+		if !strings.Contains(s, "\x1b[J") {
+			t.Error("status area was never cleared")
+		}
+		if !strings.Contains(s, "Out: hello world\n") {
+			t.Errorf("incomplete action output line:\n%q", s)
+		}
+		if !strings.Contains(s, "\x1b[J1 actions done, 1 failed, 0 not run, took ") {
+			t.Errorf("missing summary:\n%q", s)
+		}
+
+		term.Reset()
+		c, stop := make(chan time.Time, 1), make(chan struct{})
+		c <- time.Now()
+		close(stop)
+		tr.tick(c, stop)
+		fmt.Fprintln(tr.W, "late")
+		if s := term.String(); s != "late\n" {
+			t.Errorf("status area drawn after close:\n%q", s)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package gomk
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/sllm/v3"
+)
+
+// SlogTracer emits all tracer events as structured records to a
+// [slog.Handler]. The message of a record is the name of the event, e.g.
+// "CheckGoal" or "RunAction". Each record has the attributes "build" and
+// "trace" with the build ID and the path of the trace. Depending on the event,
+// records also have the attributes "project", "goal", "artefact" (the type of
+// the goal's artefact), "action", "premise", "duration" and "error".
+// Messages of Debug, Info and Warn are formatted with sllm, their arguments
+// are added as attributes.
+type SlogTracer struct {
+	h slog.Handler
+}
+
+var _ gomkore.Tracer = (*SlogTracer)(nil)
+
+func NewSlogTracer(h slog.Handler) *SlogTracer { return &SlogTracer{h: h} }
+
+func (tr *SlogTracer) Debug(t *gomkore.Trace, msg string, args ...any) {
+	tr.msg(t, slog.LevelDebug, msg, args)
+}
+
+func (tr *SlogTracer) Info(t *gomkore.Trace, msg string, args ...any) {
+	tr.msg(t, slog.LevelInfo, msg, args)
+}
+
+func (tr *SlogTracer) Warn(t *gomkore.Trace, msg string, args ...any) {
+	tr.msg(t, slog.LevelWarn, msg, args)
+}
+
+func (tr *SlogTracer) StartProject(t *gomkore.Trace, p *gomkore.Project, activity string) {
+	tr.log(t, slog.LevelInfo, "StartProject",
+		slog.String("project", p.Dir),
+		slog.String("activity", activity),
+	)
+}
+
+func (tr *SlogTracer) DoneProject(t *gomkore.Trace, p *gomkore.Project, activity string, dt time.Duration) {
+	tr.log(t, slog.LevelInfo, "DoneProject",
+		slog.String("project", p.Dir),
+		slog.String("activity", activity),
+		slog.Duration("duration", dt),
+	)
+}
+
+func (tr *SlogTracer) SetupActionEnv(t *gomkore.Trace, env *gomkore.Env) (*gomkore.Env, error) {
+	return env, nil
+}
+
+func (tr *SlogTracer) CloseActionEnv(t *gomkore.Trace, env *gomkore.Env) error { return nil }
+
+func (tr *SlogTracer) RunAction(t *gomkore.Trace, a *gomkore.Action) {
+	tr.log(t, slog.LevelInfo, "RunAction", slog.String("action", a.String()))
+}
+
+func (tr *SlogTracer) RunImplicitAction(t *gomkore.Trace, a *gomkore.Action) {
+	tr.log(t, slog.LevelDebug, "RunImplicitAction", slog.String("action", a.String()))
+}
+
+func (tr *SlogTracer) ActionDone(t *gomkore.Trace, a *gomkore.Action, dt time.Duration, err error) {
+	l := slog.LevelInfo
+	switch {
+	case err != nil:
+		l = slog.LevelError
+	case a.Op == nil:
+		l = slog.LevelDebug
+	}
+	tr.log(t, l, "ActionDone",
+		slog.String("action", a.String()),
+		slog.Duration("duration", dt),
+		errAttr(err),
+	)
+}
+
+func (tr *SlogTracer) ScheduleResTimeZero(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	tr.log(t, slog.LevelDebug, "ScheduleResTimeZero",
+		slog.String("action", a.String()),
+		goalAttr(res),
+		atfAttr(res),
+	)
+}
+
+func (tr *SlogTracer) ScheduleNotPremises(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	tr.log(t, slog.LevelDebug, "ScheduleNotPremises",
+		slog.String("action", a.String()),
+		goalAttr(res),
+		atfAttr(res),
+	)
+}
+
+func (tr *SlogTracer) SchedulePreTimeZero(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+	tr.log(t, slog.LevelDebug, "SchedulePreTimeZero",
+		slog.String("action", a.String()),
+		goalAttr(res),
+		atfAttr(res),
+		slog.String("premise", pre.Name()),
+	)
+}
+
+func (tr *SlogTracer) ScheduleOutdated(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+	tr.log(t, slog.LevelDebug, "ScheduleOutdated",
+		slog.String("action", a.String()),
+		goalAttr(res),
+		atfAttr(res),
+		slog.String("premise", pre.Name()),
+	)
+}
+
+func (tr *SlogTracer) ScheduleHashChanged(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	tr.log(t, slog.LevelDebug, "ScheduleHashChanged",
+		slog.String("action", a.String()),
+		goalAttr(res),
+		atfAttr(res),
+	)
+}
+
+func (tr *SlogTracer) CheckGoal(t *gomkore.Trace, g *gomkore.Goal) {
+	tr.log(t, slog.LevelDebug, "CheckGoal", goalAttr(g), atfAttr(g))
+}
+
+func (tr *SlogTracer) GoalUpToDate(t *gomkore.Trace, g *gomkore.Goal) {
+	tr.log(t, slog.LevelInfo, "GoalUpToDate", goalAttr(g), atfAttr(g))
+}
+
+func (tr *SlogTracer) GoalNeedsActions(t *gomkore.Trace, g *gomkore.Goal, n int) {
+	tr.log(t, slog.LevelInfo, "GoalNeedsActions",
+		goalAttr(g),
+		atfAttr(g),
+		slog.Int("actions", n),
+	)
+}
+
+func (tr *SlogTracer) GoalDone(t *gomkore.Trace, g *gomkore.Goal, dt time.Duration, err error) {
+	l := slog.LevelDebug
+	if err != nil {
+		l = slog.LevelError
+	}
+	tr.log(t, l, "GoalDone",
+		goalAttr(g),
+		atfAttr(g),
+		slog.Duration("duration", dt),
+		errAttr(err),
+	)
+}
+
+func (tr *SlogTracer) RemoveArtefact(t *gomkore.Trace, g *gomkore.Goal) {
+	tr.log(t, slog.LevelInfo, "RemoveArtefact", goalAttr(g), atfAttr(g))
+}
+
+func (tr *SlogTracer) msg(t *gomkore.Trace, l slog.Level, msg string, args []any) {
+	ctx := traceCtx(t)
+	if !tr.h.Enabled(ctx, l) {
+		return
+	}
+	buf, err := sllm.Append(nil, msg, sllmArgs(args).append)
+	if err != nil {
+		buf = []byte(msg)
+	}
+	r := slog.NewRecord(time.Now(), l, string(buf), 0)
+	r.AddAttrs(
+		slog.Uint64("build", t.Build()),
+		slog.String("trace", t.Path()),
+	)
+	r.Add(args...)
+	tr.h.Handle(ctx, r)
+}
+
+func (tr *SlogTracer) log(t *gomkore.Trace, l slog.Level, msg string, attrs ...slog.Attr) {
+	ctx := traceCtx(t)
+	if !tr.h.Enabled(ctx, l) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), l, msg, 0)
+	r.AddAttrs(
+		slog.Uint64("build", t.Build()),
+		slog.String("trace", t.Path()),
+	)
+	for _, a := range attrs {
+		if a.Key != "" {
+			r.AddAttrs(a)
+		}
+	}
+	tr.h.Handle(ctx, r)
+}
+
+func traceCtx(t *gomkore.Trace) context.Context {
+	if ctx := t.Ctx(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+func goalAttr(g *gomkore.Goal) slog.Attr { return slog.String("goal", g.Name()) }
+
+func atfAttr(g *gomkore.Goal) slog.Attr {
+	return slog.String("artefact", reflect.Indirect(reflect.ValueOf(g.Artefact)).Type().Name())
+}
+
+// errAttr returns an empty attribute if err is nil, which is then omitted.
+func errAttr(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	return slog.String("error", err.Error())
+}
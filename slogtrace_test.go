@@ -0,0 +1,50 @@
+package gomk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/testerr"
+)
+
+func TestSlogTracer(t *testing.T) {
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		prj.AbstractGoal("x").By(OpFunc("make x", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+			return errors.New("broken")
+		}))
+	})).BeNil(t)
+	var buf bytes.Buffer
+	tr := NewSlogTracer(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	build := NewBuilder(gomkore.NewTrace(context.Background(), tr), nil)
+	if err := build.Project(prj); err == nil {
+		t.Fatal("build did not fail")
+	}
+
+	events := make(map[string]map[string]any)
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec map[string]any
+		testerr.Shall(dec.Decode(&rec)).BeNil(t)
+		if _, ok := rec["build"]; !ok {
+			t.Errorf("record without build: %v", rec)
+		}
+		events[rec["msg"].(string)] = rec
+	}
+	for _, e := range []string{"StartProject", "CheckGoal", "ScheduleResTimeZero", "RunAction", "ActionDone", "GoalDone"} {
+		if events[e] == nil {
+			t.Errorf("missing event %s", e)
+		}
+	}
+	if rec := events["CheckGoal"]; rec["goal"] != "x" || rec["artefact"] != "Abstract" {
+		t.Errorf("unexpected CheckGoal record: %v", rec)
+	}
+	if rec := events["ActionDone"]; rec["action"] != "make x" || rec["error"] != "broken" || rec["level"] != "ERROR" {
+		t.Errorf("unexpected ActionDone record: %v", rec)
+	}
+}
@@ -45,6 +45,10 @@ func (tr TestTracer) RunImplicitAction(_ *gomkore.Trace, a *gomkore.Action) {
 	tr.t.Logf("gomk-RunImplicitAction: %s", a)
 }
 
+func (tr TestTracer) ActionDone(_ *gomkore.Trace, a *gomkore.Action, dt time.Duration, err error) {
+	tr.t.Logf("gomk-ActionDone: %s %s %v", a, dt, err)
+}
+
 func (tr TestTracer) ScheduleResTimeZero(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
 	tr.t.Logf("gomk-ScheduleResTimeZero: %s:> %s", a, res)
 }
@@ -61,6 +65,10 @@ func (tr TestTracer) ScheduleOutdated(t *gomkore.Trace, a *gomkore.Action, res,
 	tr.t.Logf("gomk-ScheduleOutdated: %s: %s > %s", a, pre, res)
 }
 
+func (tr TestTracer) ScheduleHashChanged(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	tr.t.Logf("gomk-ScheduleHashChanged: %s:> %s", a, res)
+}
+
 func (tr TestTracer) CheckGoal(t *gomkore.Trace, g *gomkore.Goal) {
 	tr.t.Logf("gomk-CheckGoal: %s", g)
 }
@@ -73,6 +81,10 @@ func (tr TestTracer) GoalNeedsActions(t *gomkore.Trace, g *gomkore.Goal, n int)
 	tr.t.Logf("gomk-GoalNeedsActions: %s %d", g, n)
 }
 
+func (tr TestTracer) GoalDone(t *gomkore.Trace, g *gomkore.Goal, dt time.Duration, err error) {
+	tr.t.Logf("gomk-GoalDone: %s %s %v", g, dt, err)
+}
+
 func (tr TestTracer) RemoveArtefact(t *gomkore.Trace, g *gomkore.Goal) {
 	tr.t.Logf("gomk-RemoveArtefact: %s", g)
 }
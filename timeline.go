@@ -0,0 +1,286 @@
+package gomk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+)
+
+// TimelineTracer writes the Chrome trace event format that can be loaded into
+// trace viewers like Perfetto or chrome://tracing. Projects, goals and actions
+// are written as nested duration events. Concurrently built goals and actions
+// are placed in different lanes, one per worker. Warnings and removed
+// artefacts are written as instant events. Call [TimelineTracer.Close] after
+// the build to complete the output.
+type TimelineTracer struct {
+	w     io.Writer
+	start time.Time
+
+	mu    sync.Mutex
+	n     int
+	err   error
+	spans map[any]*tlSpan
+	lanes [][]any
+}
+
+var _ gomkore.Tracer = (*TimelineTracer)(nil)
+
+type tlSpan struct {
+	name, cat string
+	start     time.Time
+	lane      int
+	args      map[string]any
+}
+
+type tlEvent struct {
+	Name  string         `json:"name,omitempty"`
+	Cat   string         `json:"cat,omitempty"`
+	Ph    string         `json:"ph"`
+	TS    float64        `json:"ts"`
+	Dur   *float64       `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+func NewTimelineTracer(w io.Writer) *TimelineTracer {
+	return &TimelineTracer{
+		w:     w,
+		start: time.Now(),
+		spans: make(map[any]*tlSpan),
+	}
+}
+
+// Close writes the end of the trace event array. It returns the first error
+// that occurred when writing events.
+func (tr *TimelineTracer) Close() error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.n == 0 {
+		tr.write("[")
+	}
+	tr.write("\n]\n")
+	return tr.err
+}
+
+func (tr *TimelineTracer) Debug(t *gomkore.Trace, msg string, args ...any) {}
+func (tr *TimelineTracer) Info(t *gomkore.Trace, msg string, args ...any)  {}
+
+func (tr *TimelineTracer) Warn(t *gomkore.Trace, msg string, args ...any) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.event(tlEvent{
+		Name:  msg,
+		Cat:   "warn",
+		Ph:    "i",
+		TS:    tr.ts(time.Now()),
+		PID:   1,
+		Scope: "g",
+	})
+}
+
+func (tr *TimelineTracer) StartProject(t *gomkore.Trace, p *gomkore.Project, activity string) {
+	parent := tlFrameKey(t)
+	if t.Top() == p {
+		parent = tlEnclosing(t)
+	}
+	tr.open(p, parent, &tlSpan{
+		name: fmt.Sprintf("%s %s", activity, p),
+		cat:  "project",
+		args: map[string]any{"dir": p.Dir},
+	})
+}
+
+func (tr *TimelineTracer) DoneProject(t *gomkore.Trace, p *gomkore.Project, activity string, dt time.Duration) {
+	tr.close(p, nil)
+}
+
+func (tr *TimelineTracer) SetupActionEnv(t *gomkore.Trace, env *gomkore.Env) (*gomkore.Env, error) {
+	return env, nil
+}
+
+func (tr *TimelineTracer) CloseActionEnv(t *gomkore.Trace, env *gomkore.Env) error { return nil }
+
+func (tr *TimelineTracer) RunAction(t *gomkore.Trace, a *gomkore.Action) {
+	tr.open(t.TopID(), tlEnclosing(t), &tlSpan{
+		name: a.String(),
+		cat:  "action",
+	})
+}
+
+func (tr *TimelineTracer) RunImplicitAction(t *gomkore.Trace, a *gomkore.Action) {}
+
+func (tr *TimelineTracer) ActionDone(t *gomkore.Trace, a *gomkore.Action, dt time.Duration, err error) {
+	if a.Op != nil {
+		tr.close(t.TopID(), err)
+	}
+}
+
+func (tr *TimelineTracer) ScheduleResTimeZero(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+}
+
+func (tr *TimelineTracer) ScheduleNotPremises(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+}
+
+func (tr *TimelineTracer) SchedulePreTimeZero(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+}
+
+func (tr *TimelineTracer) ScheduleOutdated(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
+}
+
+func (tr *TimelineTracer) ScheduleHashChanged(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+}
+
+func (tr *TimelineTracer) CheckGoal(t *gomkore.Trace, g *gomkore.Goal) {
+	parent := tlEnclosing(t)
+	if parent == nil {
+		parent = g.Project()
+	}
+	tr.open(t.TopID(), parent, &tlSpan{
+		name: g.Name(),
+		cat:  "goal",
+		args: map[string]any{
+			"artefact": reflect.Indirect(reflect.ValueOf(g.Artefact)).Type().Name(),
+		},
+	})
+}
+
+func (tr *TimelineTracer) GoalUpToDate(t *gomkore.Trace, g *gomkore.Goal) {}
+
+func (tr *TimelineTracer) GoalNeedsActions(t *gomkore.Trace, g *gomkore.Goal, n int) {}
+
+func (tr *TimelineTracer) GoalDone(t *gomkore.Trace, g *gomkore.Goal, dt time.Duration, err error) {
+	tr.close(t.TopID(), err)
+}
+
+func (tr *TimelineTracer) RemoveArtefact(t *gomkore.Trace, g *gomkore.Goal) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.event(tlEvent{
+		Name:  "remove " + g.Name(),
+		Cat:   "clean",
+		Ph:    "i",
+		TS:    tr.ts(time.Now()),
+		PID:   1,
+		Scope: "g",
+	})
+}
+
+// open starts span sp with key. It is put in the lane of its parent span if
+// the parent is the innermost span of that lane. Otherwise, the first empty
+// lane or a new lane is used.
+func (tr *TimelineTracer) open(key, parent any, sp *tlSpan) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	sp.start, sp.lane = time.Now(), -1
+	if parent != nil {
+		for i, l := range tr.lanes {
+			if len(l) > 0 && l[len(l)-1] == parent {
+				sp.lane = i
+				break
+			}
+		}
+	}
+	if sp.lane < 0 {
+		sp.lane = slices.IndexFunc(tr.lanes, func(l []any) bool { return len(l) == 0 })
+	}
+	if sp.lane < 0 {
+		sp.lane = len(tr.lanes)
+		tr.lanes = append(tr.lanes, nil)
+		tr.event(tlEvent{
+			Name: "thread_name",
+			Ph:   "M",
+			PID:  1,
+			TID:  sp.lane + 1,
+			Args: map[string]any{"name": fmt.Sprintf("worker %d", sp.lane+1)},
+		})
+	}
+	tr.lanes[sp.lane] = append(tr.lanes[sp.lane], key)
+	tr.spans[key] = sp
+}
+
+func (tr *TimelineTracer) close(key any, err error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	sp := tr.spans[key]
+	if sp == nil {
+		return
+	}
+	delete(tr.spans, key)
+	l := tr.lanes[sp.lane]
+	if i := slices.Index(l, key); i >= 0 {
+		tr.lanes[sp.lane] = slices.Delete(l, i, i+1)
+	}
+	now := time.Now()
+	dur := float64(now.Sub(sp.start).Nanoseconds()) / 1e3
+	if err != nil {
+		if sp.args == nil {
+			sp.args = make(map[string]any)
+		}
+		sp.args["error"] = err.Error()
+	}
+	tr.event(tlEvent{
+		Name: sp.name,
+		Cat:  sp.cat,
+		Ph:   "X",
+		TS:   tr.ts(sp.start),
+		Dur:  &dur,
+		PID:  1,
+		TID:  sp.lane + 1,
+		Args: sp.args,
+	})
+}
+
+func (tr *TimelineTracer) ts(t time.Time) float64 {
+	return float64(t.Sub(tr.start).Nanoseconds()) / 1e3
+}
+
+func (tr *TimelineTracer) event(e tlEvent) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		tr.err = err
+		return
+	}
+	if tr.n == 0 {
+		tr.write("[\n")
+	} else {
+		tr.write(",\n")
+	}
+	tr.n++
+	tr.write(string(data))
+}
+
+func (tr *TimelineTracer) write(s string) {
+	if tr.err == nil {
+		_, tr.err = io.WriteString(tr.w, s)
+	}
+}
+
+// tlFrameKey returns the span key for the top element of t.
+func tlFrameKey(t *gomkore.Trace) any {
+	switch o := t.Top().(type) {
+	case *gomkore.Goal, *gomkore.Action:
+		return t.TopID()
+	case *gomkore.Project:
+		return o
+	}
+	return nil
+}
+
+// tlEnclosing returns the span key of the innermost element enclosing the top
+// element of t.
+func tlEnclosing(t *gomkore.Trace) any {
+	for u := t.Up(); u != nil; u = u.Up() {
+		if k := tlFrameKey(u); k != nil {
+			return k
+		}
+	}
+	return nil
+}
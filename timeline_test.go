@@ -0,0 +1,59 @@
+package gomk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"git.fractalqb.de/fractalqb/gomk/gomkore"
+	"git.fractalqb.de/fractalqb/testerr"
+)
+
+func TestTimelineTracer(t *testing.T) {
+	sleep := OpFunc("sleep", func(*gomkore.Trace, *gomkore.Action, *gomkore.Env) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	prj := gomkore.NewProject(t.Name())
+	testerr.Shall(Edit(prj, func(prj ProjectEd) {
+		a, _ := prj.AbstractGoal("a").By(sleep)
+		b, _ := prj.AbstractGoal("b").By(sleep)
+		prj.AbstractGoal("all").ImpliedBy(a, b)
+	})).BeNil(t)
+	var buf bytes.Buffer
+	tr := NewTimelineTracer(&buf)
+	build := NewBuilder(gomkore.NewTrace(context.Background(), tr), nil)
+	build.MaxJobs = 2
+	testerr.Shall(build.Project(prj)).BeNil(t)
+	testerr.Shall(tr.Close()).BeNil(t)
+
+	var events []tlEvent
+	testerr.Shall(json.Unmarshal(buf.Bytes(), &events)).BeNil(t)
+	lanes := make(map[int]bool)
+	spans := make(map[string]int)
+	for _, e := range events {
+		if e.Ph != "X" {
+			continue
+		}
+		spans[e.Cat+":"+e.Name]++
+		if e.Cat == "action" {
+			lanes[e.TID] = true
+		}
+	}
+	if len(lanes) != 2 {
+		t.Errorf("actions in %d lanes", len(lanes))
+	}
+	for s, n := range map[string]int{
+		"project:building " + t.Name(): 1,
+		"goal:all":                     1,
+		"goal:a":                       1,
+		"goal:b":                       1,
+		"action:sleep":                 2,
+	} {
+		if spans[s] != n {
+			t.Errorf("%d spans %s, want %d", spans[s], s, n)
+		}
+	}
+}
@@ -32,6 +32,9 @@ func (cfg TraceLevel) Traces(l TraceLevel) bool { return cfg&l == l }
 type WriteTracer struct {
 	W   io.Writer
 	Log TraceLevel
+
+	// Output controls when the output of actions is written.
+	Output OutputMode
 }
 
 func NewDefaultTracer() *WriteTracer {
@@ -58,33 +61,45 @@ func (tr *WriteTracer) ParseLevelFlag(f string) error {
 	return nil
 }
 
+const WriteTraceOutputFlagDoc = `Set action output: d/direct; b/buffered; q/quiet`
+
+func (tr *WriteTracer) ParseOutputFlag(f string) error {
+	switch f {
+	case "":
+		return nil
+	case "direct", "d":
+		tr.Output = OutputDirect
+	case "buffered", "b":
+		tr.Output = OutputBuffered
+	case "quiet", "q":
+		tr.Output = OutputQuiet
+	default:
+		return fmt.Errorf("write tracer: illegal output flag '%s'", f)
+	}
+	return nil
+}
+
 func (tr WriteTracer) Debug(t *gomkore.Trace, msg string, args ...any) {
 	if tr.Log.Traces(TraceDetails) {
-		fmt.Fprintf(tr.W, "%d@%s\t  DEBUG ", t.Build(), t.TopTag())
-		sllm.Fprint(tr.W, msg, sllmArgs(args).append)
-		fmt.Fprintln(tr.W)
+		tr.log(t, "DEBUG", msg, args)
 	}
 }
 
 func (tr WriteTracer) Info(t *gomkore.Trace, msg string, args ...any) {
 	if tr.Log.Traces(TraceNormal) {
-		fmt.Fprintf(tr.W, "%d@%s\t  INFO  ", t.Build(), t.TopTag())
-		sllm.Fprint(tr.W, msg, sllmArgs(args).append)
-		fmt.Fprintln(tr.W)
+		tr.log(t, "INFO ", msg, args)
 	}
 }
 
 func (tr WriteTracer) Warn(t *gomkore.Trace, msg string, args ...any) {
 	if tr.Log.Traces(TraceImportant) {
-		fmt.Fprintf(tr.W, "%d@%s\t  WARN  ", t.Build(), t.TopTag())
-		sllm.Fprint(tr.W, msg, sllmArgs(args).append)
-		fmt.Fprintln(tr.W)
+		tr.log(t, "WARN ", msg, args)
 	}
 }
 
 func (tr WriteTracer) StartProject(t *gomkore.Trace, p *gomkore.Project, activity string) {
 	if tr.Log != 0 {
-		fmt.Fprintf(tr.W, "%d@%s\t{ %s project '%s' in %s\n",
+		tr.printf("%d@%s\t{ %s project '%s' in %s\n",
 			t.Build(),
 			t.TopTag(),
 			activity,
@@ -96,7 +111,7 @@ func (tr WriteTracer) StartProject(t *gomkore.Trace, p *gomkore.Project, activit
 
 func (tr WriteTracer) DoneProject(t *gomkore.Trace, p *gomkore.Project, activity string, dt time.Duration) {
 	if tr.Log != 0 {
-		fmt.Fprintf(tr.W, "%d@%s\t} %s project '%s' took %s\n",
+		tr.printf("%d@%s\t} %s project '%s' took %s\n",
 			t.Build(),
 			t.TopTag(),
 			activity,
@@ -121,26 +136,72 @@ func (tr WriteTracer) SetupActionEnv(t *gomkore.Trace, env *gomkore.Env) (*gomko
 		fmt.Fprintf(&pre, "%d@%s Err: ", t.Build(), t.TopTag())
 		e.Err = newPrefixWriter(e.Err, pre.Bytes())
 	}
+	if tr.Output != OutputDirect {
+		out := new(actionOutput)
+		if e.Out != nil {
+			e.Out = out.writer(e.Out)
+		}
+		if e.Err != nil {
+			e.Err = out.writer(e.Err)
+		}
+	}
 	return e, nil
 }
 
-func (tr WriteTracer) CloseActionEnv(t *gomkore.Trace, env *gomkore.Env) error { return nil }
+// CloseActionEnv writes the captured output of the action depending on the
+// [OutputMode] of tr.
+func (tr WriteTracer) CloseActionEnv(t *gomkore.Trace, env *gomkore.Env) error {
+	var out *actionOutput
+	if w, ok := env.Out.(*outputWriter); ok {
+		out = w.o
+	} else if w, ok := env.Err.(*outputWriter); ok {
+		out = w.o
+	} else {
+		return nil
+	}
+	if tr.Output == OutputQuiet {
+		if a, ok := t.Top().(*gomkore.Action); ok && a.LastError() == nil {
+			out.discard()
+			return nil
+		}
+	}
+	return out.flush()
+}
 
 func (tr WriteTracer) RunAction(t *gomkore.Trace, a *gomkore.Action) {
 	if tr.Log.Traces(TraceImportant) {
-		fmt.Fprintf(tr.W, "%d@%s\t  run action (%s)\n", t.Build(), t.TopTag(), a)
+		tr.printf("%d@%s\t  run action (%s)\n", t.Build(), t.TopTag(), a)
 	}
 }
 
 func (tr WriteTracer) RunImplicitAction(t *gomkore.Trace, _ *gomkore.Action) {
 	if tr.Log.Traces(TraceDetails) {
-		fmt.Fprintf(tr.W, "%d@%s\t  implicit action\n", t.Build(), t.TopTag())
+		tr.printf("%d@%s\t  implicit action\n", t.Build(), t.TopTag())
+	}
+}
+
+func (tr WriteTracer) ActionDone(t *gomkore.Trace, a *gomkore.Action, dt time.Duration, err error) {
+	switch {
+	case err != nil:
+		if tr.Log.Traces(TraceImportant) {
+			tr.printf("%d@%s\t  action (%s) failed after %s: %s\n",
+				t.Build(),
+				t.TopTag(),
+				a,
+				dt,
+				err,
+			)
+		}
+	case a.Op == nil:
+		return
+	case tr.Log.Traces(TraceNormal):
+		tr.printf("%d@%s\t  action (%s) took %s\n", t.Build(), t.TopTag(), a, dt)
 	}
 }
 
 func (tr WriteTracer) ScheduleResTimeZero(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
 	if tr.Log.Traces(TraceNormal) {
-		fmt.Fprintf(tr.W, "%d@%s\t  schedule (%s) for result [%s] without state time\n",
+		tr.printf("%d@%s\t  schedule (%s) for result [%s] without state time\n",
 			t.Build(),
 			t.TopTag(),
 			a,
@@ -151,7 +212,7 @@ func (tr WriteTracer) ScheduleResTimeZero(t *gomkore.Trace, a *gomkore.Action, r
 
 func (tr WriteTracer) ScheduleNotPremises(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
 	if tr.Log.Traces(TraceNormal) {
-		fmt.Fprintf(tr.W, "%d@%s\t  schedule (%s) without premise for result [%s]\n",
+		tr.printf("%d@%s\t  schedule (%s) without premise for result [%s]\n",
 			t.Build(),
 			t.TopTag(),
 			a,
@@ -162,7 +223,7 @@ func (tr WriteTracer) ScheduleNotPremises(t *gomkore.Trace, a *gomkore.Action, r
 
 func (tr WriteTracer) SchedulePreTimeZero(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
 	if tr.Log.Traces(TraceNormal) {
-		fmt.Fprintf(tr.W, "%d@%s\t  schedule (%s) for result [%s], premise [%s] has no state time\n",
+		tr.printf("%d@%s\t  schedule (%s) for result [%s], premise [%s] has no state time\n",
 			t.Build(),
 			t.TopTag(),
 			a,
@@ -174,7 +235,7 @@ func (tr WriteTracer) SchedulePreTimeZero(t *gomkore.Trace, a *gomkore.Action, r
 
 func (tr WriteTracer) ScheduleOutdated(t *gomkore.Trace, a *gomkore.Action, res, pre *gomkore.Goal) {
 	if tr.Log.Traces(TraceNormal) {
-		fmt.Fprintf(tr.W, "%d@%s\t  schedule (%s) for result [%s], premise [%s] is newer\n",
+		tr.printf("%d@%s\t  schedule (%s) for result [%s], premise [%s] is newer\n",
 			t.Build(),
 			t.TopTag(),
 			a,
@@ -184,9 +245,20 @@ func (tr WriteTracer) ScheduleOutdated(t *gomkore.Trace, a *gomkore.Action, res,
 	}
 }
 
+func (tr WriteTracer) ScheduleHashChanged(t *gomkore.Trace, a *gomkore.Action, res *gomkore.Goal) {
+	if tr.Log.Traces(TraceNormal) {
+		tr.printf("%d@%s\t  schedule (%s) for result [%s], fingerprint changed\n",
+			t.Build(),
+			t.TopTag(),
+			a,
+			res,
+		)
+	}
+}
+
 func (tr WriteTracer) CheckGoal(t *gomkore.Trace, g *gomkore.Goal) {
 	if tr.Log.Traces(TraceImportant) {
-		fmt.Fprintf(tr.W, "%d@%s\t? [%s] %s\n",
+		tr.printf("%d@%s\t? [%s] %s\n",
 			t.Build(),
 			t.TopTag(),
 			g,
@@ -197,7 +269,7 @@ func (tr WriteTracer) CheckGoal(t *gomkore.Trace, g *gomkore.Goal) {
 
 func (tr WriteTracer) GoalUpToDate(t *gomkore.Trace, g *gomkore.Goal) {
 	if tr.Log.Traces(TraceImportant) {
-		fmt.Fprintf(tr.W, "%d@%s\t. [%s] is up-to-date\n",
+		tr.printf("%d@%s\t. [%s] is up-to-date\n",
 			t.Build(),
 			t.TopTag(),
 			g,
@@ -207,7 +279,7 @@ func (tr WriteTracer) GoalUpToDate(t *gomkore.Trace, g *gomkore.Goal) {
 
 func (tr WriteTracer) GoalNeedsActions(t *gomkore.Trace, g *gomkore.Goal, n int) {
 	if tr.Log.Traces(TraceImportant) {
-		fmt.Fprintf(tr.W, "%d@%s\t! [%s] needs %d actions\n",
+		tr.printf("%d@%s\t! [%s] needs %d actions\n",
 			t.Build(),
 			t.TopTag(),
 			g,
@@ -216,9 +288,30 @@ func (tr WriteTracer) GoalNeedsActions(t *gomkore.Trace, g *gomkore.Goal, n int)
 	}
 }
 
+func (tr WriteTracer) GoalDone(t *gomkore.Trace, g *gomkore.Goal, dt time.Duration, err error) {
+	switch {
+	case err != nil:
+		if tr.Log.Traces(TraceImportant) {
+			tr.printf("%d@%s\t# [%s] failed after %s\n",
+				t.Build(),
+				t.TopTag(),
+				g,
+				dt,
+			)
+		}
+	case tr.Log.Traces(TraceDetails):
+		tr.printf("%d@%s\t# [%s] done after %s\n",
+			t.Build(),
+			t.TopTag(),
+			g,
+			dt,
+		)
+	}
+}
+
 func (tr WriteTracer) RemoveArtefact(t *gomkore.Trace, g *gomkore.Goal) {
 	if tr.Log.Traces(TraceImportant) {
-		fmt.Fprintf(tr.W, "%d@%s\t! remove artefact [%s]\n",
+		tr.printf("%d@%s\t! remove artefact [%s]\n",
 			t.Build(),
 			t.TopTag(),
 			g,
@@ -226,6 +319,24 @@ func (tr WriteTracer) RemoveArtefact(t *gomkore.Trace, g *gomkore.Goal) {
 	}
 }
 
+// printf writes a trace line to tr.W. It holds outputMu so the line does not
+// end up inside a block of buffered action output.
+func (tr WriteTracer) printf(format string, args ...any) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	fmt.Fprintf(tr.W, format, args...)
+}
+
+func (tr WriteTracer) log(t *gomkore.Trace, level, msg string, args []any) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d@%s\t  %s ", t.Build(), t.TopTag(), level)
+	sllm.Fprint(&buf, msg, sllmArgs(args).append)
+	buf.WriteByte('\n')
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	tr.W.Write(buf.Bytes())
+}
+
 type sllmArgs []any
 
 func (as sllmArgs) append(buf []byte, _ int, n string) ([]byte, error) {